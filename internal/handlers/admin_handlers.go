@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"effectiveMobile/internal/storage"
+	"effectiveMobile/internal/usecase"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+type AdminHandler struct {
+	infoClient usecase.ResilientSongInfoClient
+	dbStats    func() storage.PoolStats
+	infoLog    *log.Logger
+	errorLog   *log.Logger
+}
+
+// NewAdminHandler создаёт обработчик служебных запросов.
+// infoClient может быть nil, если обогащение песен отключено,
+// dbStats — если хранилище работает без пула соединений.
+func NewAdminHandler(infoClient usecase.ResilientSongInfoClient, dbStats func() storage.PoolStats, infoLog, errorLog *log.Logger) *AdminHandler {
+	return &AdminHandler{
+		infoClient: infoClient,
+		dbStats:    dbStats,
+		infoLog:    infoLog,
+		errorLog:   errorLog}
+}
+
+// Get song info circuit breaker state godoc
+// @Summary      Song info breaker state
+// @Description  get circuit breaker state of external song info API
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  usecase.BreakerStats
+// @Failure      404  {object}  ErrorResponse
+// @Router       /api/admin/info-breaker [get]
+func (h *AdminHandler) GetInfoBreaker(w http.ResponseWriter, r *http.Request) {
+	h.infoLog.Println("Получаем состояние выключателя внешнего API")
+
+	if h.infoClient == nil {
+		writeError(w, r, h.errorLog, fmt.Errorf("обогащение песен отключено: %w", usecase.ErrNotFound))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(h.infoClient.BreakerStats())
+}
+
+// Get database pool stats godoc
+// @Summary      Database pool stats
+// @Description  get connection pool statistics
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  storage.PoolStats
+// @Failure      404  {object}  ErrorResponse
+// @Router       /api/admin/db-stats [get]
+func (h *AdminHandler) GetDBStats(w http.ResponseWriter, r *http.Request) {
+	h.infoLog.Println("Получаем статистику пула соединений")
+
+	if h.dbStats == nil {
+		writeError(w, r, h.errorLog, fmt.Errorf("хранилище работает без пула соединений: %w", usecase.ErrNotFound))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(h.dbStats())
+}
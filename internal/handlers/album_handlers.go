@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"effectiveMobile/internal/usecase"
+	"effectiveMobile/models"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+type AlbumHandler struct {
+	albumUsecase usecase.AlbumUsecase
+	infoLog      *log.Logger
+	errorLog     *log.Logger
+}
+
+func NewAlbumHandler(albumUsecase usecase.AlbumUsecase, infoLog, errorLog *log.Logger) *AlbumHandler {
+	return &AlbumHandler{
+		albumUsecase: albumUsecase,
+		infoLog:      infoLog,
+		errorLog:     errorLog}
+}
+
+// List albums godoc
+// @Summary      List albums
+// @Description  get albums page ordered by release date, albums without a date go last
+// @Tags         album
+// @Produce      json
+// @Param        title     query  string  false  "Part of the album title"
+// @Param        group_id  query  int     false  "Group ID"
+// @Param        type      query  string  false  "Album type"  Enums(lp, ep, single, compilation)
+// @Param        page      query  int     false  "Page number, starting from 1"
+// @Param        limit     query  int     false  "Albums per page"
+// @Success      200  {object}  models.AlbumPage
+// @Failure      422  {object}  ErrorResponse
+// @Router       /api/albums [get]
+func (h *AlbumHandler) GetAllAlbums(w http.ResponseWriter, r *http.Request) {
+	h.infoLog.Println("Получаем альбомы")
+
+	query := r.URL.Query()
+	filter := models.AlbumFilter{
+		Title: query.Get("title"),
+		Type:  models.AlbumType(query.Get("type")),
+	}
+
+	if value := query.Get("group_id"); value != "" {
+		groupID, err := strconv.Atoi(value)
+		if err != nil {
+			writeError(w, r, h.errorLog, usecase.InvalidField("group_id", "должно быть целым числом"))
+			return
+		}
+		filter.GroupID = &groupID
+	}
+
+	var page models.Pagination
+	var err error
+	if page.Page, err = queryInt(r, "page"); err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+	if page.Limit, err = queryInt(r, "limit"); err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	albums, err := h.albumUsecase.GetAllAlbums(r.Context(), filter, page)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(albums)
+}
+
+// Get album godoc
+// @Summary      Get album
+// @Description  get album with its group and number of tracks
+// @Tags         album
+// @Produce      json
+// @Param        id   path      int  true  "Album ID"
+// @Success      200  {object}  models.Album
+// @Failure      404  {object}  ErrorResponse
+// @Router       /api/albums/{id} [get]
+func (h *AlbumHandler) GetAlbumByID(w http.ResponseWriter, r *http.Request) {
+	h.infoLog.Println("Получаем альбом по ID")
+
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	album, err := h.albumUsecase.GetAlbumByID(r.Context(), id)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(album)
+}
+
+// List album tracks godoc
+// @Summary      List album tracks
+// @Description  get album and its songs ordered by disc and track number, songs without a track number go last on their disc
+// @Tags         album
+// @Produce      json
+// @Param        id   path      int  true  "Album ID"
+// @Success      200  {object}  models.AlbumTracks
+// @Failure      404  {object}  ErrorResponse
+// @Router       /api/albums/{id}/tracks [get]
+func (h *AlbumHandler) GetAlbumTracks(w http.ResponseWriter, r *http.Request) {
+	h.infoLog.Println("Получаем песни альбома")
+
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	tracks, err := h.albumUsecase.GetAlbumTracks(r.Context(), id)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(tracks)
+}
+
+// Create album godoc
+// @Summary      Create album
+// @Description  add album, group is set by id or by group_name; a group given by name is created if it does not exist
+// @Tags         album
+// @Accept       json
+// @Produce      json
+// @Param        album  body      models.AlbumInput  true  "Album title, group, release date, type and cover"
+// @Success      201  {object}  models.Album
+// @Failure      400  {object}  ErrorResponse
+// @Failure      422  {object}  ErrorResponse
+// @Router       /api/albums [post]
+func (h *AlbumHandler) CreateAlbum(w http.ResponseWriter, r *http.Request) {
+	h.infoLog.Println("Создаём альбом")
+
+	defer r.Body.Close()
+
+	var request models.AlbumInput
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, r, h.errorLog, badRequest(err))
+		return
+	}
+
+	id, err := h.albumUsecase.CreateAlbum(r.Context(), request)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	album, err := h.albumUsecase.GetAlbumByID(r.Context(), id)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/api/albums/%d", id))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(album)
+}
+
+// Update album godoc
+// @Summary      Update album
+// @Description  replace album fields, omitted fields are cleared
+// @Tags         album
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int                true  "Album ID"
+// @Param        album  body      models.AlbumInput  true  "New album title, group, release date, type and cover"
+// @Success      200  {object}  models.Album
+// @Failure      400  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      422  {object}  ErrorResponse
+// @Router       /api/albums/{id} [put]
+func (h *AlbumHandler) UpdateAlbum(w http.ResponseWriter, r *http.Request) {
+	h.infoLog.Println("Обновляем альбом по ID")
+
+	defer r.Body.Close()
+
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	var request models.AlbumInput
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, r, h.errorLog, badRequest(err))
+		return
+	}
+
+	if err := h.albumUsecase.UpdateAlbum(r.Context(), id, request); err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	album, err := h.albumUsecase.GetAlbumByID(r.Context(), id)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(album)
+}
+
+// Delete album godoc
+// @Summary      Delete album
+// @Description  delete album, its songs stay in the catalogue without an album
+// @Tags         album
+// @Param        id   path  int  true  "Album ID"
+// @Success      204
+// @Failure      404  {object}  ErrorResponse
+// @Router       /api/albums/{id} [delete]
+func (h *AlbumHandler) DeleteAlbum(w http.ResponseWriter, r *http.Request) {
+	h.infoLog.Println("Удаляем альбом по ID")
+
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	if err := h.albumUsecase.DeleteAlbum(r.Context(), id); err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,16 @@
+package handlers
+
+import "net/http"
+
+// maxBodyBytes ограничивает тело запроса: самая длинная допустимая песня
+// с текстом занимает меньше 100 КБ
+const maxBodyBytes = 1 << 20
+
+// LimitBody не даёт прочитать тело запроса больше maxBodyBytes,
+// такой запрос отклоняется с кодом 413
+func LimitBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+		next.ServeHTTP(w, r)
+	})
+}
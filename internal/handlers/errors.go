@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"effectiveMobile/internal/usecase"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// ErrorResponse — тело ответа с ошибкой. Code не зависит от языка сообщения
+// и предназначен для клиентов, request_id помогает найти запрос в логах.
+type ErrorResponse struct {
+	Code    string               `json:"code"`
+	Message string               `json:"message"`
+	Details []usecase.FieldError `json:"details,omitempty"`
+	// ID записи, с которой конфликтует запрос
+	ExistingID int    `json:"existing_id,omitempty"`
+	RequestID  string `json:"request_id,omitempty"`
+}
+
+// errBadRequest означает, что тело запроса не удалось разобрать
+var errBadRequest = errors.New("неправильный запрос")
+
+// errUnsupportedMediaType означает, что тело запроса пришло в неподдерживаемом формате
+var errUnsupportedMediaType = errors.New("неподдерживаемый тип содержимого")
+
+func badRequest(err error) error {
+	return errors.Join(errBadRequest, err)
+}
+
+// writeError переводит ошибку в статус HTTP и тело ErrorResponse.
+// Это единственное место, где ошибки слоёв ниже сопоставляются со статусами.
+func writeError(w http.ResponseWriter, r *http.Request, errorLog *log.Logger, err error) {
+	requestID := RequestIDFromContext(r.Context())
+	response := ErrorResponse{RequestID: requestID}
+	status := http.StatusInternalServerError
+
+	var validationErr *usecase.ValidationError
+	var maxBytesErr *http.MaxBytesError
+	var conflictErr *usecase.ConflictError
+	switch {
+	case errors.As(err, &validationErr):
+		status = http.StatusUnprocessableEntity
+		response.Code = "validation_failed"
+		response.Message = "Данные не прошли проверку"
+		response.Details = validationErr.Fields
+	case errors.As(err, &maxBytesErr):
+		status = http.StatusRequestEntityTooLarge
+		response.Code = "payload_too_large"
+		response.Message = fmt.Sprintf("Тело запроса больше %d байт", maxBytesErr.Limit)
+	case errors.Is(err, errBadRequest):
+		status = http.StatusBadRequest
+		response.Code = "bad_request"
+		response.Message = "Неправильный запрос"
+	case errors.Is(err, errUnsupportedMediaType):
+		status = http.StatusUnsupportedMediaType
+		response.Code = "unsupported_media_type"
+		response.Message = "Неподдерживаемый тип содержимого"
+	case errors.As(err, &conflictErr):
+		status = http.StatusConflict
+		response.Code = "conflict"
+		response.Message = "Запись уже существует"
+		response.ExistingID = conflictErr.ID
+	case errors.Is(err, usecase.ErrGroupNotEmpty):
+		status = http.StatusConflict
+		response.Code = "group_not_empty"
+		response.Message = "У группы есть песни, удалите их или передайте cascade=true"
+	case errors.Is(err, usecase.ErrNotFound):
+		status = http.StatusNotFound
+		response.Code = "not_found"
+		response.Message = "Запись не найдена"
+	case errors.Is(err, usecase.ErrConflict):
+		status = http.StatusConflict
+		response.Code = "conflict"
+		response.Message = "Запись уже существует"
+	case errors.Is(err, usecase.ErrPreconditionFailed):
+		status = http.StatusPreconditionFailed
+		response.Code = "precondition_failed"
+		response.Message = "Запись изменилась, перечитайте её и повторите запрос"
+	case errors.Is(err, errPreconditionRequired):
+		status = http.StatusPreconditionRequired
+		response.Code = "precondition_required"
+		response.Message = "Укажите ETag записи в заголовке If-Match"
+	default:
+		response.Code = "internal"
+		response.Message = "Внутренняя ошибка сервера"
+	}
+
+	errorLog.Printf("[%s] %s %s: %d: %v", requestID, r.Method, r.URL.Path, status, err)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,178 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"effectiveMobile/internal/handlers"
+	"effectiveMobile/internal/storage"
+	"effectiveMobile/internal/usecase"
+	"effectiveMobile/models"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// newTestServer поднимает маршруты песен и групп, как в main, над хранилищем в памяти
+// с группой Muse и её песней Hysteria
+func newTestServer(t *testing.T) (server *httptest.Server, groupID, songID int) {
+	t.Helper()
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+	s := storage.NewMemorySongStorage(logger, logger)
+
+	groupID, err := s.AddGroup(ctx, models.Group{Name: ptr("Muse")})
+	if err != nil {
+		t.Fatalf("AddGroup: %v", err)
+	}
+	songID, err = s.AddSong(ctx, models.Song{Group: &groupID, Name: ptr("Hysteria")})
+	if err != nil {
+		t.Fatalf("AddSong: %v", err)
+	}
+
+	songs := handlers.NewSongHandler(usecase.NewSongUsecase(s, nil, logger, logger), logger, logger)
+	groups := handlers.NewGroupHandler(usecase.NewGroupUsecase(s, logger, logger), logger, logger)
+
+	router := mux.NewRouter()
+	router.Use(handlers.RequestID, handlers.LimitBody)
+	v2 := router.PathPrefix("/api/v2").Subrouter()
+	v2.HandleFunc("/songs", songs.CreateSong).Methods("POST")
+	v2.HandleFunc("/songs/{id:[0-9]+}", songs.GetSongByID).Methods("GET")
+	v2.HandleFunc("/songs/{id:[0-9]+}", songs.ReplaceSong).Methods("PUT")
+	v2.HandleFunc("/songs/{id:[0-9]+}", songs.PatchSong).Methods("PATCH")
+	v2.HandleFunc("/groups/{id:[0-9]+}", groups.DeleteGroup).Methods("DELETE")
+	router.HandleFunc("/api/song/{id:[0-9]+}", handlers.Deprecated("/api/v2/songs/{id}", songs.GetSongByID)).Methods("GET")
+
+	server = httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server, groupID, songID
+}
+
+func TestErrorResponses(t *testing.T) {
+	server, groupID, songID := newTestServer(t)
+	song := "/api/v2/songs/" + strconv.Itoa(songID)
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		header  http.Header
+		body    string
+		status  int
+		code    string
+		details []string
+		// ID записи в existing_id
+		existing int
+	}{
+		{
+			name: "несуществующая песня", method: "GET", path: "/api/v2/songs/1000",
+			status: http.StatusNotFound, code: "not_found",
+		},
+		{
+			name: "несуществующая песня в v1", method: "GET", path: "/api/song/1000",
+			status: http.StatusNotFound, code: "not_found",
+		},
+		{
+			name: "тело не JSON", method: "POST", path: "/api/v2/songs", body: `{"group":`,
+			status: http.StatusBadRequest, code: "bad_request",
+		},
+		{
+			name: "ошибки нескольких полей", method: "POST", path: "/api/v2/songs", body: `{"group": " ", "song": ""}`,
+			status: http.StatusUnprocessableEntity, code: "validation_failed", details: []string{"group", "song"},
+		},
+		{
+			name: "песня уже есть у группы", method: "POST", path: "/api/v2/songs", body: `{"group": "Muse", "song": "HYSTERIA"}`,
+			status: http.StatusConflict, code: "conflict", existing: songID,
+		},
+		{
+			name: "тело больше предела", method: "POST", path: "/api/v2/songs",
+			body:   `{"group": "Muse", "song": "` + strings.Repeat("a", 2<<20) + `"}`,
+			status: http.StatusRequestEntityTooLarge, code: "payload_too_large",
+		},
+		{
+			name: "патч не в JSON", method: "PATCH", path: song, header: http.Header{"Content-Type": {"text/plain"}},
+			body: "song=Uprising", status: http.StatusUnsupportedMediaType, code: "unsupported_media_type",
+		},
+		{
+			name: "замена без If-Match", method: "PUT", path: song, body: `{"song": "Hysteria"}`,
+			status: http.StatusPreconditionRequired, code: "precondition_required",
+		},
+		{
+			name: "замена со старой версией", method: "PUT", path: song, header: http.Header{"If-Match": {`"99"`}},
+			body: `{"song": "Hysteria"}`, status: http.StatusPreconditionFailed, code: "precondition_failed",
+		},
+		{
+			name: "удаление группы с песнями", method: "DELETE", path: "/api/v2/groups/" + strconv.Itoa(groupID),
+			status: http.StatusConflict, code: "group_not_empty",
+		},
+	}
+
+	for _, tt := range tests {
+		req, err := http.NewRequest(tt.method, server.URL+tt.path, strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for key, values := range tt.header {
+			req.Header[key] = values
+		}
+		req.Header.Set("X-Request-ID", "test-"+tt.method)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var body handlers.ErrorResponse
+		decodeErr := json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+
+		if resp.StatusCode != tt.status || decodeErr != nil {
+			t.Errorf("%s: статус %d (%v), ожидался %d", tt.name, resp.StatusCode, decodeErr, tt.status)
+			continue
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("%s: Content-Type %q", tt.name, ct)
+		}
+		if body.Code != tt.code || body.Message == "" || body.RequestID != "test-"+tt.method || body.ExistingID != tt.existing {
+			t.Errorf("%s: тело %+v", tt.name, body)
+		}
+
+		details := make([]string, len(body.Details))
+		for i, detail := range body.Details {
+			details[i] = detail.Field
+		}
+		slices.Sort(details)
+		if !slices.Equal(details, tt.details) && (len(details) > 0 || len(tt.details) > 0) {
+			t.Errorf("%s: поля с ошибками %v, ожидались %v", tt.name, details, tt.details)
+		}
+	}
+}
+
+// Ответ с ошибкой без X-Request-ID в запросе содержит созданный идентификатор
+func TestErrorResponseRequestID(t *testing.T) {
+	server, _, _ := newTestServer(t)
+
+	resp, err := http.Post(server.URL+"/api/v2/songs", "application/json", bytes.NewBufferString("["))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var body handlers.ErrorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.RequestID == "" || body.RequestID != resp.Header.Get("X-Request-ID") {
+		t.Errorf("request_id %q, заголовок %q", body.RequestID, resp.Header.Get("X-Request-ID"))
+	}
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"effectiveMobile/internal/usecase"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// errPreconditionRequired означает, что изменяющий запрос пришёл без If-Match
+var errPreconditionRequired = errors.New("нужен заголовок If-Match")
+
+// songETag возвращает сильный ETag для версии песни
+func songETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// parseETags разбирает список ETag из заголовков If-Match и If-None-Match
+func parseETags(header string) []string {
+	var tags []string
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// notModified сравнивает If-None-Match с версией песни. Для чтения
+// достаточно слабого сравнения, поэтому префикс W/ не учитывается.
+func notModified(r *http.Request, version int) bool {
+	etag := songETag(version)
+	for _, tag := range parseETags(r.Header.Get("If-None-Match")) {
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// expectedVersion возвращает версию песни из If-Match. Заголовок обязателен,
+// * подходит к любой версии. ETag сравниваются строго, слабые не подходят.
+// Если в списке несколько ETag, берётся тот, что совпадает с текущей версией.
+func (h *SongHandler) expectedVersion(r *http.Request, id int) (int, error) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return 0, errPreconditionRequired
+	}
+
+	var versions []int
+	for _, tag := range parseETags(header) {
+		if tag == "*" {
+			return usecase.AnyVersion, nil
+		}
+
+		version, err := strconv.Atoi(strings.Trim(tag, `"`))
+		if err == nil && version > 0 && tag == songETag(version) {
+			versions = append(versions, version)
+		}
+	}
+
+	switch len(versions) {
+	case 0:
+		return 0, usecase.ErrPreconditionFailed
+	case 1:
+		return versions[0], nil
+	}
+
+	song, err := h.songUsecase.GetSongByID(r.Context(), id)
+	if err != nil {
+		return 0, err
+	}
+	if !slices.Contains(versions, song.Version) {
+		return 0, usecase.ErrPreconditionFailed
+	}
+	return song.Version, nil
+}
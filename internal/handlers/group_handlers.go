@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"effectiveMobile/internal/usecase"
+	"effectiveMobile/models"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+type GroupHandler struct {
+	groupUsecase usecase.GroupUsecase
+	infoLog      *log.Logger
+	errorLog     *log.Logger
+}
+
+func NewGroupHandler(groupUsecase usecase.GroupUsecase, infoLog, errorLog *log.Logger) *GroupHandler {
+	return &GroupHandler{
+		groupUsecase: groupUsecase,
+		infoLog:      infoLog,
+		errorLog:     errorLog}
+}
+
+// List groups godoc
+// @Summary      List groups
+// @Description  get groups page ordered by name
+// @Tags         group
+// @Produce      json
+// @Param        name   query  string  false  "Part of the group name"
+// @Param        genre  query  string  false  "Only groups with this genre"
+// @Param        page   query  int     false  "Page number, starting from 1"
+// @Param        limit  query  int     false  "Groups per page"
+// @Success      200  {object}  models.GroupPage
+// @Failure      422  {object}  ErrorResponse
+// @Router       /api/v2/groups [get]
+func (h *GroupHandler) GetAllGroups(w http.ResponseWriter, r *http.Request) {
+	h.infoLog.Println("Получаем группы")
+
+	filter := models.GroupFilter{
+		Name:  r.URL.Query().Get("name"),
+		Genre: r.URL.Query().Get("genre"),
+	}
+
+	var page models.Pagination
+	var err error
+	if page.Page, err = queryInt(r, "page"); err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+	if page.Limit, err = queryInt(r, "limit"); err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	groups, err := h.groupUsecase.GetAllGroups(r.Context(), filter, page)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(groups)
+}
+
+// Get group godoc
+// @Summary      Get group
+// @Description  get group with its description and number of songs, ID of a merged group redirects to the group it was merged into
+// @Tags         group
+// @Produce      json
+// @Param        id   path      int  true  "Group ID"
+// @Success      200  {object}  models.Group
+// @Success      301  {object}  models.Group
+// @Failure      404  {object}  ErrorResponse
+// @Router       /api/v2/groups/{id} [get]
+func (h *GroupHandler) GetGroupByID(w http.ResponseWriter, r *http.Request) {
+	h.infoLog.Println("Получаем группу по ID")
+
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	group, err := h.groupUsecase.GetGroupByID(r.Context(), id)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	if group.ID != id {
+		// Группа влита в другую, старый ID ведёт на неё
+		w.Header().Set("Location", fmt.Sprintf("/api/v2/groups/%d", group.ID))
+		w.WriteHeader(http.StatusMovedPermanently)
+		json.NewEncoder(w).Encode(group)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(group)
+}
+
+// List group songs godoc
+// @Summary      List group songs
+// @Description  get songs of the group, pagination and sorting are the same as for the song list
+// @Tags         group
+// @Produce      json
+// @Param        id     path   int     true   "Group ID"
+// @Param        page   query  int     false  "Page number, starting from 1"
+// @Param        limit  query  int     false  "Songs per page"
+// @Param        after  query  string  false  "Cursor from next_cursor of the previous page"
+// @Param        sort   query  string  false  "Sort order, e.g. release_date:desc"
+// @Success      200  {object}  models.SongPage
+// @Failure      404  {object}  ErrorResponse
+// @Failure      422  {object}  ErrorResponse
+// @Router       /api/v2/groups/{id}/songs [get]
+func (h *GroupHandler) GetGroupSongs(w http.ResponseWriter, r *http.Request) {
+	h.infoLog.Println("Получаем песни группы")
+
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	page, err := parsePagination(r)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	songs, err := h.groupUsecase.GetGroupSongs(r.Context(), id, page)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(songs)
+}
+
+// Create group godoc
+// @Summary      Create group
+// @Description  add group with description, 409 with existing_id if the name is taken
+// @Tags         group
+// @Accept       json
+// @Produce      json
+// @Param        group  body      models.Group  true  "Group name and description"
+// @Success      201  {object}  models.Group
+// @Failure      400  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Failure      422  {object}  ErrorResponse
+// @Router       /api/v2/groups [post]
+func (h *GroupHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	h.infoLog.Println("Создаём группу")
+
+	defer r.Body.Close()
+
+	var request models.Group
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, r, h.errorLog, badRequest(err))
+		return
+	}
+
+	id, err := h.groupUsecase.CreateGroup(r.Context(), request)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	group, err := h.groupUsecase.GetGroupByID(r.Context(), id)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/api/v2/groups/%d", id))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(group)
+}
+
+// Update group godoc
+// @Summary      Update group
+// @Description  rename group and replace its description, omitted fields are cleared
+// @Tags         group
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int           true  "Group ID"
+// @Param        group  body      models.Group  true  "New group name and description"
+// @Success      200  {object}  models.Group
+// @Failure      400  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Failure      422  {object}  ErrorResponse
+// @Router       /api/v2/groups/{id} [put]
+func (h *GroupHandler) UpdateGroup(w http.ResponseWriter, r *http.Request) {
+	h.infoLog.Println("Обновляем группу по ID")
+
+	defer r.Body.Close()
+
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	var request models.Group
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, r, h.errorLog, badRequest(err))
+		return
+	}
+
+	if err := h.groupUsecase.UpdateGroup(r.Context(), id, request); err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	group, err := h.groupUsecase.GetGroupByID(r.Context(), id)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(group)
+}
+
+// Delete group godoc
+// @Summary      Delete group
+// @Description  delete group without songs, with cascade=true its songs are deleted too
+// @Tags         group
+// @Param        id       path   int   true   "Group ID"
+// @Param        cascade  query  bool  false  "Delete the group songs as well"
+// @Success      204
+// @Failure      404  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Failure      422  {object}  ErrorResponse
+// @Router       /api/v2/groups/{id} [delete]
+func (h *GroupHandler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	h.infoLog.Println("Удаляем группу по ID")
+
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	cascade := false
+	if value := r.URL.Query().Get("cascade"); value != "" {
+		if cascade, err = strconv.ParseBool(value); err != nil {
+			writeError(w, r, h.errorLog, usecase.InvalidField("cascade", "допустимые значения: true, false"))
+			return
+		}
+	}
+
+	if err := h.groupUsecase.DeleteGroup(r.Context(), id, cascade); err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Merge groups godoc
+// @Summary      Merge groups
+// @Description  move songs of source groups into the target group in one transaction; ids of merged groups keep resolving to the target
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        merge  body      models.GroupMerge  true  "Target group, groups to merge and strategy for songs with the same name: keep_target (default), keep_source, rename or error"
+// @Success      200  {object}  models.GroupMergeResult
+// @Failure      400  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Failure      422  {object}  ErrorResponse
+// @Router       /api/admin/groups/merge [post]
+func (h *GroupHandler) MergeGroups(w http.ResponseWriter, r *http.Request) {
+	h.infoLog.Println("Объединяем группы")
+
+	defer r.Body.Close()
+
+	var request models.GroupMerge
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, r, h.errorLog, badRequest(err))
+		return
+	}
+
+	result, err := h.groupUsecase.MergeGroups(r.Context(), request)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
@@ -1,12 +1,18 @@
 package handlers
 
 import (
-	"context"
 	"effectiveMobile/internal/usecase"
+	"effectiveMobile/internal/validation"
 	"effectiveMobile/models"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
 )
 
 type SongHandler struct {
@@ -24,15 +30,19 @@ type AddSongRequest struct {
 	Song  string `json:"song"`
 }
 
+// validate нормализует названия и проверяет их до того, как будет создана группа
+func (request *AddSongRequest) validate() error {
+	var v validation.Errors
+	v.String("group", &request.Group, validation.GroupName)
+	v.String("song", &request.Song, validation.SongName)
+	return v.Err()
+}
+
 type SongRequest struct {
 	ID      int         `json:"id"`
 	NewSong models.Song `json:"song"`
 }
 
-type BadRequest struct {
-	Message string `json:"message"`
-}
-
 func NewSongHandler(songUsecase usecase.SongUsecase, infoLog, errorLog *log.Logger) *SongHandler {
 	return &SongHandler{
 		songUsecase: songUsecase,
@@ -42,29 +52,213 @@ func NewSongHandler(songUsecase usecase.SongUsecase, infoLog, errorLog *log.Logg
 
 // Get all songs godoc
 // @Summary      List songs
-// @Description  get songs
+// @Description  get songs page. Use page/limit for offset pagination or after for cursor pagination
 // @Tags         song
 // @Produce      json
-// @Param        name   query      string  true  "Song name"
-// @Param        group   query      string  true  "Group name"
-// @Success      200  {object}  []models.Song
-// @Failure      400  {object}  BadRequest
+// @Param        name   query      string  false  "Song name"
+// @Param        group   query      string  false  "Group name"
+// @Param        group_id  query    int     false  "Group ID"
+// @Param        album   query      string  false  "Album title"
+// @Param        album_id  query    int     false  "Album ID"
+// @Param        match   query      string  false  "Name, group and album match mode"  Enums(contains, prefix, exact)
+// @Param        from    query      string  false  "Released on or after: YYYY-MM-DD, DD.MM.YYYY, YYYY-MM or YYYY"
+// @Param        to      query      string  false  "Released on or before, a partial date means the end of the period"
+// @Param        text    query      string  false  "Lyrics fragment"
+// @Param        has_link  query    bool    false  "Only songs with or without link"
+// @Param        page    query      int     false  "Page number, starting from 1"
+// @Param        limit   query      int     false  "Songs per page"
+// @Param        after   query      string  false  "Cursor from next_cursor of the previous page"
+// @Param        sort    query      string  false  "Sort order, e.g. release_date:desc,song:asc. Fields: id, song, group, release_date"
+// @Success      200  {object}  models.SongPage
+// @Failure      422  {object}  ErrorResponse
 // @Router       /api/songs [get]
 func (h *SongHandler) GetAllSongs(w http.ResponseWriter, r *http.Request) {
 	h.infoLog.Println("Получаем все песни")
-	filterName := r.URL.Query().Get("name")
-	filterGroup := r.URL.Query().Get("group")
-	ctx := context.Background()
-	songs, err := h.songUsecase.GetAllSongs(ctx, filterName, filterGroup)
 
+	filter, err := parseSongFilter(r)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	page, err := parsePagination(r)
 	if err != nil {
-		h.errorLog.Printf("Неправильный запрос: %v", err)
-		http.Error(w, "Неправильный запрос", http.StatusBadRequest)
+		writeError(w, r, h.errorLog, err)
 		return
 	}
 
-	if len(songs) == 0 {
-		http.Error(w, "Нет подходящих песен!", http.StatusOK)
+	songs, err := h.songUsecase.GetAllSongs(r.Context(), filter, page)
+
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(songs)
+}
+
+func parseSongFilter(r *http.Request) (models.SongFilter, error) {
+	query := r.URL.Query()
+	filter := models.SongFilter{
+		Name:  query.Get("name"),
+		Group: query.Get("group"),
+		Album: query.Get("album"),
+		Match: models.MatchMode(query.Get("match")),
+		Text:  query.Get("text"),
+	}
+
+	if value := query.Get("group_id"); value != "" {
+		groupID, err := strconv.Atoi(value)
+		if err != nil {
+			return filter, usecase.InvalidField("group_id", "должно быть целым числом")
+		}
+		filter.GroupID = &groupID
+	}
+	if value := query.Get("album_id"); value != "" {
+		albumID, err := strconv.Atoi(value)
+		if err != nil {
+			return filter, usecase.InvalidField("album_id", "должно быть целым числом")
+		}
+		filter.AlbumID = &albumID
+	}
+
+	// Неполная дата задаёт период: from=1988 — с начала года, to=1988 — до его конца
+	for key, target := range map[string]**time.Time{"from": &filter.ReleasedFrom, "to": &filter.ReleasedTo} {
+		if value := query.Get(key); value != "" {
+			date, precision, err := models.ParseDate(value)
+			if err != nil {
+				return filter, usecase.InvalidField(key, "допустимые форматы: YYYY-MM-DD, DD.MM.YYYY, YYYY-MM, YYYY")
+			}
+			if key == "to" {
+				date = date.End(precision)
+			}
+			*target = &date.Time
+		}
+	}
+
+	if value := query.Get("has_link"); value != "" {
+		hasLink, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, usecase.InvalidField("has_link", "допустимые значения: true, false")
+		}
+		filter.HasLink = &hasLink
+	}
+
+	return filter, nil
+}
+
+func parsePagination(r *http.Request) (models.Pagination, error) {
+	var page models.Pagination
+	var err error
+
+	if page.Page, err = queryInt(r, "page"); err != nil {
+		return page, err
+	}
+	if page.Limit, err = queryInt(r, "limit"); err != nil {
+		return page, err
+	}
+	page.After = r.URL.Query().Get("after")
+
+	page.Sort, err = parseSort(r.URL.Query().Get("sort"))
+
+	return page, err
+}
+
+// parseSort разбирает сортировку вида "release_date:desc,song:asc".
+// Направление по умолчанию — по возрастанию.
+func parseSort(value string) ([]models.SortField, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var sort []models.SortField
+	for _, part := range strings.Split(value, ",") {
+		field, direction, _ := strings.Cut(strings.TrimSpace(part), ":")
+
+		switch strings.ToLower(direction) {
+		case "", "asc":
+			sort = append(sort, models.SortField{Field: field})
+		case "desc":
+			sort = append(sort, models.SortField{Field: field, Desc: true})
+		default:
+			return nil, usecase.InvalidField("sort", fmt.Sprintf("неизвестное направление сортировки %q", direction))
+		}
+	}
+
+	return sort, nil
+}
+
+// Full-text search godoc
+// @Summary      Search songs
+// @Description  full-text search by song name, group and lyrics ranked by relevance
+// @Tags         song
+// @Produce      json
+// @Param        q       query      string  true   "Search query, websearch syntax"
+// @Param        lang    query      string  false  "Text search configuration, both if empty"  Enums(ru, en)
+// @Param        page    query      int     false  "Page number, starting from 1"
+// @Param        limit   query      int     false  "Songs per page"
+// @Success      200  {object}  models.SongSearchPage
+// @Failure      422  {object}  ErrorResponse
+// @Router       /api/songs/search [get]
+func (h *SongHandler) SearchSongs(w http.ResponseWriter, r *http.Request) {
+	h.infoLog.Println("Ищем песни")
+
+	search := models.SearchQuery{
+		Text: r.URL.Query().Get("q"),
+		Lang: r.URL.Query().Get("lang"),
+	}
+
+	page, err := parsePagination(r)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	songs, err := h.songUsecase.SearchSongs(r.Context(), search, page)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(songs)
+}
+
+// Fuzzy search godoc
+// @Summary      Fuzzy search songs
+// @Description  typo-tolerant search by song and group names ordered by similarity
+// @Tags         song
+// @Produce      json
+// @Param        q          query      string  true   "Song or group name, possibly with typos"
+// @Param        threshold  query      number  false  "Minimal similarity from 0 to 1, default 0.3"
+// @Param        limit      query      int     false  "Maximum number of songs"
+// @Success      200  {object}  models.FuzzySongPage
+// @Failure      422  {object}  ErrorResponse
+// @Router       /api/songs/fuzzy [get]
+func (h *SongHandler) FuzzySearchSongs(w http.ResponseWriter, r *http.Request) {
+	h.infoLog.Println("Ищем песни с опечатками")
+
+	var threshold *float64
+	if value := r.URL.Query().Get("threshold"); value != "" {
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			err = usecase.InvalidField("threshold", "должно быть числом")
+			writeError(w, r, h.errorLog, err)
+			return
+		}
+		threshold = &number
+	}
+
+	limit, err := queryInt(r, "limit")
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	songs, err := h.songUsecase.FuzzySearchSongs(r.Context(), r.URL.Query().Get("q"), threshold, limit)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
 		return
 	}
 
@@ -77,24 +271,33 @@ func (h *SongHandler) GetAllSongs(w http.ResponseWriter, r *http.Request) {
 // @Description  get song by ID
 // @Tags         song
 // @Produce      json
-// @Param        id   path      int  true  "Song ID"
+// @Param        id             path      int     true   "Song ID"
+// @Param        If-None-Match  header    string  false  "ETag of the cached song"
 // @Success      200  {object}  models.Song
-// @Failure      400  {object}  BadRequest
+// @Header       200  {string}  ETag  "song version"
+// @Success      304
+// @Failure      404  {object}  ErrorResponse
+// @Failure      422  {object}  ErrorResponse
 // @Router       /api/song/{id} [get]
 func (h *SongHandler) GetSongByID(w http.ResponseWriter, r *http.Request) {
 	h.infoLog.Println("Получаем песню по ID")
-	url := r.URL
-	str := url.String()
 
-	id := str[len(str)-1] - 48
-
-	defer r.Body.Close()
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
 
-	song, err := h.songUsecase.GetSongByID(r.Context(), int(id))
+	song, err := h.songUsecase.GetSongByID(r.Context(), id)
 
 	if err != nil {
-		h.errorLog.Printf("Неправильный запрос: %v", err)
-		http.Error(w, "Неправильный запрос", http.StatusBadRequest)
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	w.Header().Set("ETag", songETag(song.Version))
+	if notModified(r, song.Version) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
@@ -102,6 +305,72 @@ func (h *SongHandler) GetSongByID(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(song)
 }
 
+// Get song lyrics by verses godoc
+// @Summary      Song lyrics
+// @Description  get song text split into verses with pagination
+// @Tags         song
+// @Produce      json
+// @Param        id      path   int  true   "Song ID"
+// @Param        page    query  int  false  "Page number, starting from 1"
+// @Param        limit   query  int  false  "Verses per page"
+// @Success      200  {object}  models.LyricsPage
+// @Failure      404  {object}  ErrorResponse
+// @Failure      422  {object}  ErrorResponse
+// @Router       /api/songs/{id}/lyrics [get]
+func (h *SongHandler) GetSongLyrics(w http.ResponseWriter, r *http.Request) {
+	h.infoLog.Println("Получаем куплеты песни по ID")
+
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	page, err := queryInt(r, "page")
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	limit, err := queryInt(r, "limit")
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	lyrics, err := h.songUsecase.GetSongLyrics(r.Context(), id, page, limit)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(lyrics)
+}
+
+// queryInt читает целочисленный параметр запроса, отсутствующий параметр равен 0
+func queryInt(r *http.Request, key string) (int, error) {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return 0, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, usecase.InvalidField(key, "должно быть целым числом")
+	}
+	return number, nil
+}
+
+// pathID читает ID из пути запроса, маршрут гарантирует, что это число
+func pathID(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		return 0, usecase.InvalidField("id", "должно быть целым числом")
+	}
+	return id, nil
+}
+
 // Добавление песни запросом
 // {
 //  "group": "Muse",
@@ -114,46 +383,54 @@ func (h *SongHandler) GetSongByID(w http.ResponseWriter, r *http.Request) {
 // @Tags         song
 // @Accept       json
 // @Produce      json
+// @Param        on_conflict  query  string  false  "What to do if the group already has the song: error (default), return or update"
 // @Success      200  {string}  message
-// @Failure      400  {string}  http.BadRequest
-// @Failure      500  {string}  http.InternalServerError
-// @Router       /api/song/create [post]
+// @Failure      400  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Failure      413  {object}  ErrorResponse
+// @Failure      422  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /api/song/add [post]
 func (h *SongHandler) AddSong(w http.ResponseWriter, r *http.Request) {
 	h.infoLog.Println("Добавляем песню")
 	var song AddSongRequest
 
 	err := json.NewDecoder(r.Body).Decode(&song)
 	if err != nil {
-		h.errorLog.Printf("Неправильный запрос: %v", err)
-		http.Error(w, "Неправильный запрос", http.StatusBadRequest)
+		writeError(w, r, h.errorLog, badRequest(err))
 		return
 	}
 
 	defer r.Body.Close()
 
-	id, err := h.songUsecase.AddGroup(r.Context(), models.Group{
-		Name: &song.Group,
-	})
+	_, created, err := h.addSong(r, song)
 
 	if err != nil {
-		h.errorLog.Printf("Ошибка добавления группы: %v", err)
-		http.Error(w, "Ошибка добавления группы", http.StatusInternalServerError)
+		writeError(w, r, h.errorLog, err)
 		return
 	}
 
-	err = h.songUsecase.AddSong(r.Context(), models.Song{
-		Group: &id,
-		Name:  &song.Song,
-	})
-
-	if err != nil {
-		h.errorLog.Printf("Ошибка добавления песни: %v", err)
-		http.Error(w, "Ошибка добавления песни", http.StatusInternalServerError)
-		return
+	message := "Песня добавлена успешно"
+	if !created {
+		message = "Песня уже есть в библиотеке"
 	}
 
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"message": "Песня добавлена успешно"})
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
+
+// addSong добавляет песню группы song.Group; группа создаётся, если её ещё нет.
+// Параметр on_conflict выбирает, что делать с уже существующей песней.
+func (h *SongHandler) addSong(r *http.Request, song AddSongRequest) (int, bool, error) {
+	if err := song.validate(); err != nil {
+		return 0, false, err
+	}
+
+	onConflict := models.OnConflict(r.URL.Query().Get("on_conflict"))
+	return h.songUsecase.AddSong(r.Context(), models.Song{
+		Group_name: &song.Group,
+		Name:       &song.Song,
+	}, onConflict)
 }
 
 // Update new song
@@ -162,9 +439,15 @@ func (h *SongHandler) AddSong(w http.ResponseWriter, r *http.Request) {
 // @Tags         song
 // @Accept       json
 // @Produce      json
+// @Param        If-Match  header  string  true  "ETag of the song being changed"
 // @Success      200  {string}  message
-// @Failure      400  {string}  http.BadRequest
-// @Failure      500  {string}  http.InternalServerError
+// @Failure      400  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Failure      412  {object}  ErrorResponse
+// @Failure      422  {object}  ErrorResponse
+// @Failure      428  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
 // @Router       /api/song/update [put]
 func (h *SongHandler) UpdateSong(w http.ResponseWriter, r *http.Request) {
 	h.infoLog.Println("Обновляем песню по ID")
@@ -172,18 +455,22 @@ func (h *SongHandler) UpdateSong(w http.ResponseWriter, r *http.Request) {
 
 	err := json.NewDecoder(r.Body).Decode(&newSong)
 	if err != nil {
-		h.errorLog.Printf("Неправильный запрос: %v", err)
-		http.Error(w, "Неправильный запрос", http.StatusBadRequest)
+		writeError(w, r, h.errorLog, badRequest(err))
 		return
 	}
 
 	defer r.Body.Close()
 
-	err = h.songUsecase.UpdateSong(r.Context(), newSong.ID, newSong.NewSong)
+	version, err := h.expectedVersion(r, newSong.ID)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	err = h.songUsecase.UpdateSong(r.Context(), newSong.ID, newSong.NewSong, version)
 
 	if err != nil {
-		h.errorLog.Printf("Ошибка изменения песни: %v", err)
-		http.Error(w, "Ошибка изменения песни", http.StatusInternalServerError)
+		writeError(w, r, h.errorLog, err)
 		return
 	}
 
@@ -196,9 +483,13 @@ func (h *SongHandler) UpdateSong(w http.ResponseWriter, r *http.Request) {
 // @Tags         song
 // @Accept       json
 // @Produce      json
+// @Param        If-Match  header  string  true  "ETag of the song being deleted"
 // @Success      200  {string}  message
-// @Failure      400  {string}  http.BadRequest
-// @Failure      500  {string}  http.InternalServerError
+// @Failure      400  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      412  {object}  ErrorResponse
+// @Failure      428  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
 // @Router       /api/song/delete [delete]
 func (h *SongHandler) DeleteSong(w http.ResponseWriter, r *http.Request) {
 	h.infoLog.Println("Удаляем песню по ID")
@@ -206,18 +497,22 @@ func (h *SongHandler) DeleteSong(w http.ResponseWriter, r *http.Request) {
 
 	err := json.NewDecoder(r.Body).Decode(&songID)
 	if err != nil {
-		h.errorLog.Printf("Неправильный запрос: %v", err)
-		http.Error(w, "Неправильный запрос", http.StatusBadRequest)
+		writeError(w, r, h.errorLog, badRequest(err))
 		return
 	}
 
 	defer r.Body.Close()
 
-	err = h.songUsecase.DeleteSong(r.Context(), songID.ID)
+	version, err := h.expectedVersion(r, songID.ID)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	err = h.songUsecase.DeleteSong(r.Context(), songID.ID, version)
 
 	if err != nil {
-		h.errorLog.Printf("Ошибка удаления песни: %v", err)
-		http.Error(w, "Ошибка удаления песни", http.StatusInternalServerError)
+		writeError(w, r, h.errorLog, err)
 		return
 	}
 
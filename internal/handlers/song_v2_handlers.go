@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"bytes"
+	"effectiveMobile/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Дата, с которой маршруты v1 считаются устаревшими
+var v1DeprecatedAt = time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)
+
+// Deprecated помечает маршрут v1 устаревшим: добавляет заголовок Deprecation
+// (RFC 9745) и ссылку на маршрут v2. Параметры пути вида {id} в successor
+// заменяются значениями из запроса.
+func Deprecated(successor string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		link := successor
+		for name, value := range mux.Vars(r) {
+			link = strings.ReplaceAll(link, "{"+name+"}", value)
+		}
+
+		w.Header().Set("Deprecation", fmt.Sprintf("@%d", v1DeprecatedAt.Unix()))
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, link))
+		next(w, r)
+	}
+}
+
+// Create song godoc
+// @Summary      Create song
+// @Description  add song to library, the group is created if needed.
+// @Description  If the group already has a song with the same name (ignoring case and extra spaces),
+// @Description  on_conflict=error answers 409 with existing_id, return answers 200 with the existing song
+// @Description  and update overwrites its name spelling and external API data.
+// @Tags         song v2
+// @Accept       json
+// @Produce      json
+// @Param        song         body   AddSongRequest  true   "Group and song names"
+// @Param        on_conflict  query  string          false  "error (default), return or update"
+// @Success      201  {object}  models.Song
+// @Success      200  {object}  models.Song
+// @Failure      400  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Failure      413  {object}  ErrorResponse
+// @Failure      422  {object}  ErrorResponse
+// @Router       /api/v2/songs [post]
+func (h *SongHandler) CreateSong(w http.ResponseWriter, r *http.Request) {
+	h.infoLog.Println("Создаём песню")
+	var request AddSongRequest
+
+	defer r.Body.Close()
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, r, h.errorLog, badRequest(err))
+		return
+	}
+
+	id, created, err := h.addSong(r, request)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	song, err := h.songUsecase.GetSongByID(r.Context(), id)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	status := http.StatusCreated
+	if !created {
+		status = http.StatusOK
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/api/v2/songs/%d", id))
+	w.Header().Set("ETag", songETag(song.Version))
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(song)
+}
+
+// Replace song godoc
+// @Summary      Replace song
+// @Description  replace all song fields, omitted fields are cleared; without group and group_name the song keeps its group
+// @Tags         song v2
+// @Accept       json
+// @Produce      json
+// @Param        id        path      int          true  "Song ID"
+// @Param        If-Match  header    string       true  "ETag of the song being changed"
+// @Param        song      body      models.Song  true  "New song data"
+// @Success      200  {object}  models.Song
+// @Header       200  {string}  ETag  "new song version"
+// @Failure      400  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Failure      412  {object}  ErrorResponse
+// @Failure      422  {object}  ErrorResponse
+// @Failure      428  {object}  ErrorResponse
+// @Router       /api/v2/songs/{id} [put]
+func (h *SongHandler) ReplaceSong(w http.ResponseWriter, r *http.Request) {
+	h.infoLog.Println("Заменяем песню по ID")
+
+	defer r.Body.Close()
+
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	var newSong models.Song
+	if err := json.NewDecoder(r.Body).Decode(&newSong); err != nil {
+		writeError(w, r, h.errorLog, badRequest(err))
+		return
+	}
+
+	version, err := h.expectedVersion(r, id)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	if err := h.songUsecase.UpdateSong(r.Context(), id, newSong, version); err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	song, err := h.songUsecase.GetSongByID(r.Context(), id)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	w.Header().Set("ETag", songETag(song.Version))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(song)
+}
+
+// Patch song godoc
+// @Summary      Patch song
+// @Description  change only the supplied song fields (RFC 7396 JSON Merge Patch), null clears a field
+// @Tags         song v2
+// @Accept       application/merge-patch+json
+// @Produce      json
+// @Param        id        path      int               true  "Song ID"
+// @Param        If-Match  header    string            true  "ETag of the song being changed"
+// @Param        patch     body      models.SongPatch  true  "Fields to change"
+// @Success      200  {object}  models.Song
+// @Header       200  {string}  ETag  "new song version"
+// @Failure      400  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Failure      412  {object}  ErrorResponse
+// @Failure      415  {object}  ErrorResponse
+// @Failure      422  {object}  ErrorResponse
+// @Failure      428  {object}  ErrorResponse
+// @Router       /api/v2/songs/{id} [patch]
+// @Router       /api/songs/{id} [patch]
+func (h *SongHandler) PatchSong(w http.ResponseWriter, r *http.Request) {
+	h.infoLog.Println("Изменяем поля песни по ID")
+
+	defer r.Body.Close()
+
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	if !mergePatchMediaType(r.Header.Get("Content-Type")) {
+		writeError(w, r, h.errorLog, errUnsupportedMediaType)
+		return
+	}
+
+	patch, err := decodeSongPatch(r.Body)
+	if err != nil {
+		writeError(w, r, h.errorLog, badRequest(err))
+		return
+	}
+
+	version, err := h.expectedVersion(r, id)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	song, err := h.songUsecase.PatchSong(r.Context(), id, patch, version)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	w.Header().Set("ETag", songETag(song.Version))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(song)
+}
+
+// mergePatchMediaType принимает application/merge-patch+json, а также
+// application/json и пустой заголовок для старых клиентов
+func mergePatchMediaType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/merge-patch+json" || mediaType == "application/json"
+}
+
+// decodeSongPatch разбирает документ merge patch. Патч, который не является
+// объектом, заменил бы песню целиком, поэтому такой запрос отклоняется.
+func decodeSongPatch(body io.Reader) (models.SongPatch, error) {
+	var patch models.SongPatch
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(body).Decode(&raw); err != nil {
+		return patch, err
+	}
+
+	if !bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{")) {
+		return patch, errors.New("патч должен быть JSON-объектом")
+	}
+
+	err := json.Unmarshal(raw, &patch)
+	return patch, err
+}
+
+// Remove song godoc
+// @Summary      Delete song
+// @Description  delete song by ID
+// @Tags         song v2
+// @Param        id        path    int     true  "Song ID"
+// @Param        If-Match  header  string  true  "ETag of the song being deleted"
+// @Success      204
+// @Failure      404  {object}  ErrorResponse
+// @Failure      412  {object}  ErrorResponse
+// @Failure      422  {object}  ErrorResponse
+// @Failure      428  {object}  ErrorResponse
+// @Router       /api/v2/songs/{id} [delete]
+func (h *SongHandler) RemoveSong(w http.ResponseWriter, r *http.Request) {
+	h.infoLog.Println("Удаляем песню по ID из пути")
+
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	version, err := h.expectedVersion(r, id)
+	if err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	if err := h.songUsecase.DeleteSong(r.Context(), id, version); err != nil {
+		writeError(w, r, h.errorLog, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,75 @@
+// Package infostub — встроенная заглушка внешнего API информации о песнях.
+// Позволяет проверить добавление песен с обогащением без доступа к сети.
+package infostub
+
+import (
+	"effectiveMobile/internal/usecase"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+type songKey struct {
+	group string
+	song  string
+}
+
+type Server struct {
+	*httptest.Server
+
+	mu    sync.RWMutex
+	songs map[songKey]usecase.SongDetail
+}
+
+// NewServer запускает заглушку на свободном локальном порту.
+// Адрес сервера доступен в поле URL.
+func NewServer() *Server {
+	s := &Server{songs: make(map[songKey]usecase.SongDetail)}
+
+	s.Add("Muse", "Supermassive Black Hole", usecase.SongDetail{
+		ReleaseDate: "16.07.2006",
+		Text:        "Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\nYou caught me under false pretenses\nHow long before you let me go?\n\nOoh\nYou set my soul alight\nOoh\nYou set my soul alight",
+		Link:        "https://www.youtube.com/watch?v=Xsp3_a-PMTw",
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/info", s.handleInfo)
+	s.Server = httptest.NewServer(mux)
+
+	return s
+}
+
+// Add добавляет или заменяет ответ для пары группа/песня
+func (s *Server) Add(group, song string, detail usecase.SongDetail) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.songs[songKey{group: group, song: song}] = detail
+}
+
+func (s *Server) handleInfo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+
+	group := r.URL.Query().Get("group")
+	song := r.URL.Query().Get("song")
+	if group == "" || song == "" {
+		http.Error(w, "Неправильный запрос", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.RLock()
+	detail, ok := s.songs[songKey{group: group, song: song}]
+	s.mu.RUnlock()
+
+	if !ok {
+		http.Error(w, "Песня не найдена", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(detail)
+}
@@ -0,0 +1,182 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrNothingToRollback = errors.New("нет применённых миграций")
+
+// MigrationStatus — состояние миграции в базе
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+// database — база, к которой применяются миграции
+type database interface {
+	// locked выполняет fn под блокировкой миграций, передавая соединение,
+	// которое её держит, и применённые версии. Таблица schema_migrations
+	// к этому моменту уже создана.
+	locked(ctx context.Context, fn func(conn session, applied map[int]time.Time) error) error
+}
+
+// session — соединение, через которое выполняются миграции
+type session interface {
+	// inTx выполняет запросы exec в одной транзакции
+	inTx(ctx context.Context, fn func(exec execFunc) error) error
+}
+
+type execFunc func(query string, args ...any) error
+
+type appliedVersion struct {
+	version   int
+	appliedAt time.Time
+}
+
+func appliedMap(versions []appliedVersion) map[int]time.Time {
+	applied := make(map[int]time.Time, len(versions))
+	for _, v := range versions {
+		applied[v.version] = v.appliedAt
+	}
+	return applied
+}
+
+type Migrator struct {
+	db         database
+	migrations []Migration
+	infoLog    *log.Logger
+}
+
+// NewMigrator создаёт мигратор для Postgres
+func NewMigrator(pool *pgxpool.Pool, migrations []Migration, infoLog *log.Logger) *Migrator {
+	return &Migrator{
+		db:         postgresDatabase{pool: pool},
+		migrations: migrations,
+		infoLog:    infoLog,
+	}
+}
+
+// NewSQLiteMigrator создаёт мигратор для SQLite
+func NewSQLiteMigrator(db *sql.DB, migrations []Migration, infoLog *log.Logger) *Migrator {
+	return &Migrator{
+		db:         sqliteDatabase{db: db},
+		migrations: migrations,
+		infoLog:    infoLog,
+	}
+}
+
+// Up применяет все ещё не применённые миграции и возвращает их число
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	count := 0
+
+	err := m.db.locked(ctx, func(conn session, applied map[int]time.Time) error {
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+
+	return count, err
+}
+
+// Down откатывает n последних применённых миграций
+func (m *Migrator) Down(ctx context.Context, n int) (int, error) {
+	count := 0
+
+	err := m.db.locked(ctx, func(conn session, applied map[int]time.Time) error {
+		for i := len(m.migrations) - 1; i >= 0 && count < n; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if err := m.rollback(ctx, conn, migration); err != nil {
+				return err
+			}
+			count++
+		}
+		if count == 0 && n > 0 {
+			return ErrNothingToRollback
+		}
+		return nil
+	})
+
+	return count, err
+}
+
+// Redo откатывает и заново применяет последнюю применённую миграцию
+func (m *Migrator) Redo(ctx context.Context) error {
+	return m.db.locked(ctx, func(conn session, applied map[int]time.Time) error {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if err := m.rollback(ctx, conn, migration); err != nil {
+				return err
+			}
+			return m.apply(ctx, conn, migration)
+		}
+		return ErrNothingToRollback
+	})
+}
+
+// Status возвращает все известные миграции с датой применения,
+// у неприменённых миграций AppliedAt равен nil
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+
+	err := m.db.locked(ctx, func(_ session, applied map[int]time.Time) error {
+		for _, migration := range m.migrations {
+			status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+			if appliedAt, ok := applied[migration.Version]; ok {
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+
+	return statuses, err
+}
+
+// apply выполняет миграцию и отмечает её применённой в одной транзакции
+func (m *Migrator) apply(ctx context.Context, conn session, migration Migration) error {
+	m.infoLog.Printf("Применяем миграцию %04d_%s", migration.Version, migration.Name)
+
+	return conn.inTx(ctx, func(exec execFunc) error {
+		if err := exec(migration.Up); err != nil {
+			return fmt.Errorf("миграция %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+		return exec("INSERT INTO schema_migrations (version, name) VALUES ($1, $2)",
+			migration.Version, migration.Name)
+	})
+}
+
+func (m *Migrator) rollback(ctx context.Context, conn session, migration Migration) error {
+	m.infoLog.Printf("Откатываем миграцию %04d_%s", migration.Version, migration.Name)
+
+	if migration.Down == "" {
+		return fmt.Errorf("у миграции %04d_%s нет файла down", migration.Version, migration.Name)
+	}
+
+	return conn.inTx(ctx, func(exec execFunc) error {
+		if err := exec(migration.Down); err != nil {
+			return fmt.Errorf("откат %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+		return exec("DELETE FROM schema_migrations WHERE version = $1", migration.Version)
+	})
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS songs;
+DROP TABLE IF EXISTS groups;
@@ -0,0 +1,22 @@
+-- Базовая схема. IF NOT EXISTS позволяет принять под управление базу,
+-- созданную раньше из music.sql.
+CREATE TABLE IF NOT EXISTS groups (
+    id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    group_name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS songs (
+    id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    group_id INTEGER,
+    song_name TEXT NOT NULL UNIQUE,
+    release_date TEXT DEFAULT NOW()::date,
+    text TEXT,
+    link TEXT,
+    FOREIGN KEY (group_id) REFERENCES groups(id)
+);
+
+-- Состояние обогащения данными внешнего API
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS needs_enrichment BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS enrich_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS enrich_last_error TEXT;
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS enrich_last_attempt TIMESTAMPTZ;
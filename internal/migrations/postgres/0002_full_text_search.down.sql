@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS groups_search_update ON groups;
+DROP TRIGGER IF EXISTS songs_search_update ON songs;
+DROP FUNCTION IF EXISTS groups_search_update();
+DROP FUNCTION IF EXISTS songs_search_update();
+
+ALTER TABLE songs DROP COLUMN IF EXISTS search_ru;
+ALTER TABLE songs DROP COLUMN IF EXISTS search_en;
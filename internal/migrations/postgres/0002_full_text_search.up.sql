@@ -0,0 +1,48 @@
+-- Полнотекстовый поиск: векторы по названию (A), группе (B) и тексту (C)
+-- на русском и английском. Название группы лежит в другой таблице,
+-- поэтому векторы поддерживаются триггерами, а не генерируемыми столбцами.
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS search_ru TSVECTOR;
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS search_en TSVECTOR;
+
+CREATE OR REPLACE FUNCTION songs_search_update() RETURNS trigger AS $$
+DECLARE
+    group_title TEXT;
+BEGIN
+    SELECT group_name INTO group_title FROM groups WHERE id = NEW.group_id;
+
+    NEW.search_ru :=
+        setweight(to_tsvector('russian', coalesce(NEW.song_name, '')), 'A') ||
+        setweight(to_tsvector('russian', coalesce(group_title, '')), 'B') ||
+        setweight(to_tsvector('russian', coalesce(NEW.text, '')), 'C');
+    NEW.search_en :=
+        setweight(to_tsvector('english', coalesce(NEW.song_name, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(group_title, '')), 'B') ||
+        setweight(to_tsvector('english', coalesce(NEW.text, '')), 'C');
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS songs_search_update ON songs;
+CREATE TRIGGER songs_search_update
+    BEFORE INSERT OR UPDATE OF song_name, group_id, text ON songs
+    FOR EACH ROW EXECUTE FUNCTION songs_search_update();
+
+-- При переименовании группы пересчитываем векторы её песен
+CREATE OR REPLACE FUNCTION groups_search_update() RETURNS trigger AS $$
+BEGIN
+    UPDATE songs SET group_id = group_id WHERE group_id = NEW.id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS groups_search_update ON groups;
+CREATE TRIGGER groups_search_update
+    AFTER UPDATE OF group_name ON groups
+    FOR EACH ROW EXECUTE FUNCTION groups_search_update();
+
+-- Заполняем векторы уже существующих песен
+UPDATE songs SET group_id = group_id;
+
+CREATE INDEX IF NOT EXISTS songs_search_ru_idx ON songs USING GIN (search_ru);
+CREATE INDEX IF NOT EXISTS songs_search_en_idx ON songs USING GIN (search_en);
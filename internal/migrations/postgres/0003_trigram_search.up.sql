@@ -0,0 +1,5 @@
+-- Нечёткий поиск по названиям с опечатками
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS songs_song_name_trgm_idx ON songs USING GIN (song_name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS groups_group_name_trgm_idx ON groups USING GIN (group_name gin_trgm_ops);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS songs_search_key_trgm_idx;
+DROP INDEX IF EXISTS groups_search_key_trgm_idx;
+
+ALTER TABLE groups DROP COLUMN IF EXISTS search_key;
+ALTER TABLE songs DROP COLUMN IF EXISTS search_key;
@@ -0,0 +1,7 @@
+-- Транслитерированные названия без регистра и диакритики.
+-- Ключи вычисляет приложение, пустые ключи оно заполняет при запуске.
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS search_key TEXT;
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS search_key TEXT;
+
+CREATE INDEX IF NOT EXISTS songs_search_key_trgm_idx ON songs USING GIN (search_key gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS groups_search_key_trgm_idx ON groups USING GIN (search_key gin_trgm_ops);
@@ -0,0 +1,12 @@
+DELETE FROM songs s
+USING groups g, (VALUES
+    ('Imagine Dragons', 'Believer'),
+    ('Imagine Dragons', 'Thunder'),
+    ('Linkin Park', 'In the end')
+) AS v(group_name, song_name)
+WHERE s.group_id = g.id
+    AND g.group_name = v.group_name
+    AND s.song_name = v.song_name;
+DELETE FROM groups g
+WHERE g.group_name IN ('Imagine Dragons', 'Linkin Park')
+    AND NOT EXISTS (SELECT 1 FROM songs s WHERE s.group_id = g.id);
@@ -0,0 +1,15 @@
+-- Начальные данные, раньше загружались из music.sql
+INSERT INTO groups(group_name) VALUES
+('Imagine Dragons'),
+('Linkin Park')
+ON CONFLICT (group_name) DO NOTHING;
+
+INSERT INTO songs(group_id, song_name, text, link)
+SELECT g.id, v.song_name, v.text, v.link
+FROM (VALUES
+    ('Imagine Dragons', 'Believer', 'I am believer', 'https://youtu.be/7wtfhZwyrcc?si=AhOKtDFQw19Cmfmy'),
+    ('Imagine Dragons', 'Thunder', 'Before the thunder', 'https://youtu.be/fKopy74weus?si=PPbCVQS28w3Fp0Ga'),
+    ('Linkin Park', 'In the end', 'It doens''t even matter', 'https://youtu.be/eVTXPUF4Oz4?si=XRrAbzJJqOO4jAJx')
+) AS v(group_name, song_name, text, link)
+INNER JOIN groups g ON g.group_name = v.group_name
+ON CONFLICT (song_name) DO NOTHING;
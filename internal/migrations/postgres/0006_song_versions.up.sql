@@ -0,0 +1,3 @@
+-- Версия строки для оптимистичной блокировки: каждое изменение песни
+-- увеличивает её на единицу, клиенты получают версию в заголовке ETag.
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
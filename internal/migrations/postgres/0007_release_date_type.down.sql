@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS songs_release_date_idx;
+
+ALTER TABLE songs
+    ALTER COLUMN release_date DROP DEFAULT,
+    ALTER COLUMN release_date TYPE TEXT USING to_char(release_date, 'YYYY-MM-DD'),
+    ALTER COLUMN release_date SET DEFAULT NOW()::date;
+
+-- Возвращаем значения, которые не удалось разобрать
+UPDATE songs s SET release_date = r.value
+FROM release_date_rejects r
+WHERE r.song_id = s.id AND s.release_date IS NULL;
+
+ALTER TABLE songs DROP COLUMN IF EXISTS release_date_precision;
+DROP TABLE IF EXISTS release_date_rejects;
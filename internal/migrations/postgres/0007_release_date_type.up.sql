@@ -0,0 +1,54 @@
+-- Дата релиза хранилась строкой, и сравнения по диапазону работали только
+-- для значений в формате YYYY-MM-DD. Переводим столбец в DATE и сохраняем
+-- точность даты: день, месяц или год. Нераспознанные значения
+-- не теряются, а переносятся в release_date_rejects. Значения по умолчанию
+-- у даты больше нет: неизвестная дата остаётся NULL, а не становится сегодняшней.
+CREATE TABLE IF NOT EXISTS release_date_rejects (
+    song_id INTEGER NOT NULL,
+    value TEXT NOT NULL,
+    rejected_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Форматы те же, что принимает API: YYYY-MM-DD (в том числе с временем),
+-- DD.MM.YYYY, YYYY-MM, MM.YYYY и YYYY. Несуществующие даты дают NULL.
+CREATE OR REPLACE FUNCTION parse_release_date(raw TEXT, OUT parsed DATE, OUT date_precision TEXT) AS $$
+BEGIN
+    raw := btrim(raw);
+    IF raw ~ '^\d{4}-\d{2}-\d{2}' THEN
+        parsed := left(raw, 10)::date;
+        date_precision := 'day';
+    ELSIF raw ~ '^\d{2}\.\d{2}\.\d{4}$' THEN
+        parsed := make_date(substr(raw, 7, 4)::int, substr(raw, 4, 2)::int, substr(raw, 1, 2)::int);
+        date_precision := 'day';
+    ELSIF raw ~ '^\d{4}-\d{2}$' THEN
+        parsed := make_date(left(raw, 4)::int, right(raw, 2)::int, 1);
+        date_precision := 'month';
+    ELSIF raw ~ '^\d{2}\.\d{4}$' THEN
+        parsed := make_date(right(raw, 4)::int, left(raw, 2)::int, 1);
+        date_precision := 'month';
+    ELSIF raw ~ '^\d{4}$' THEN
+        parsed := make_date(raw::int, 1, 1);
+        date_precision := 'year';
+    END IF;
+EXCEPTION WHEN others THEN
+    parsed := NULL;
+    date_precision := NULL;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+INSERT INTO release_date_rejects (song_id, value)
+SELECT id, release_date FROM songs
+WHERE release_date IS NOT NULL AND (parse_release_date(release_date)).parsed IS NULL;
+
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS release_date_precision TEXT NOT NULL DEFAULT 'day'
+    CHECK (release_date_precision IN ('day', 'month', 'year'));
+
+UPDATE songs SET release_date_precision = COALESCE((parse_release_date(release_date)).date_precision, 'day');
+
+ALTER TABLE songs
+    ALTER COLUMN release_date DROP DEFAULT,
+    ALTER COLUMN release_date TYPE DATE USING (parse_release_date(release_date)).parsed;
+
+DROP FUNCTION parse_release_date(TEXT);
+
+CREATE INDEX IF NOT EXISTS songs_release_date_idx ON songs (release_date);
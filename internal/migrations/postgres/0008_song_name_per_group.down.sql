@@ -0,0 +1,4 @@
+-- Откат не пройдёт, если у разных групп уже есть песни с одинаковым названием
+DROP INDEX IF EXISTS songs_group_name_key_idx;
+ALTER TABLE songs DROP COLUMN IF EXISTS name_key;
+ALTER TABLE songs ADD CONSTRAINT songs_song_name_key UNIQUE (song_name);
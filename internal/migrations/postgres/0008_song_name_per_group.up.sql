@@ -0,0 +1,7 @@
+-- Название песни уникально в пределах группы, а не во всей библиотеке.
+-- Названия сравниваются по ключу name_key (без учёта регистра и лишних
+-- пробелов); ключи вычисляет приложение, пустые ключи оно заполняет при запуске.
+ALTER TABLE songs DROP CONSTRAINT IF EXISTS songs_song_name_key;
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS name_key TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS songs_group_name_key_idx ON songs (group_id, name_key);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS groups_genres_idx;
+ALTER TABLE groups DROP COLUMN IF EXISTS bio;
+ALTER TABLE groups DROP COLUMN IF EXISTS genres;
+ALTER TABLE groups DROP COLUMN IF EXISTS formed_year;
+ALTER TABLE groups DROP COLUMN IF EXISTS country;
@@ -0,0 +1,7 @@
+-- Описание групп: страна (ISO 3166-1 alpha-2), год основания, жанры и биография
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS country TEXT;
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS formed_year INTEGER;
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS genres JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS bio TEXT;
+
+CREATE INDEX IF NOT EXISTS groups_genres_idx ON groups USING GIN (genres);
@@ -0,0 +1,8 @@
+-- ID групп, влитых в другую группу. По старому ID находится группа,
+-- в которую её объединили; при удалении этой группы псевдонимы удаляются.
+CREATE TABLE IF NOT EXISTS group_aliases (
+    id INTEGER PRIMARY KEY,
+    group_id INTEGER NOT NULL REFERENCES groups (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS group_aliases_group_id_idx ON group_aliases (group_id);
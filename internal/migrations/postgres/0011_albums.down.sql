@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS songs_album_track_idx;
+DROP INDEX IF EXISTS songs_album_id_idx;
+ALTER TABLE songs DROP COLUMN IF EXISTS track_number;
+ALTER TABLE songs DROP COLUMN IF EXISTS disc_number;
+ALTER TABLE songs DROP COLUMN IF EXISTS album_id;
+DROP TABLE IF EXISTS albums;
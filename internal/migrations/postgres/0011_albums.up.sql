@@ -0,0 +1,27 @@
+-- Альбомы и место песни в альбоме. Альбомы удаляются вместе с группой,
+-- а при удалении альбома его песни остаются без альбома (DeleteAlbum
+-- заодно очищает номера дисков и треков).
+CREATE TABLE IF NOT EXISTS albums (
+    id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    title TEXT NOT NULL,
+    search_key TEXT,
+    group_id INTEGER REFERENCES groups (id) ON DELETE CASCADE,
+    release_date DATE,
+    release_date_precision TEXT NOT NULL DEFAULT 'day'
+        CHECK (release_date_precision IN ('day', 'month', 'year')),
+    album_type TEXT NOT NULL DEFAULT 'lp'
+        CHECK (album_type IN ('lp', 'ep', 'single', 'compilation')),
+    cover TEXT
+);
+
+CREATE INDEX IF NOT EXISTS albums_group_id_idx ON albums (group_id);
+CREATE INDEX IF NOT EXISTS albums_search_key_idx ON albums (search_key);
+
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS album_id INTEGER REFERENCES albums (id) ON DELETE SET NULL;
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS disc_number INTEGER CHECK (disc_number > 0);
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS track_number INTEGER CHECK (track_number > 0);
+
+CREATE INDEX IF NOT EXISTS songs_album_id_idx ON songs (album_id);
+-- Место в альбоме занимает одна песня; диск без номера считается первым
+CREATE UNIQUE INDEX IF NOT EXISTS songs_album_track_idx
+    ON songs (album_id, COALESCE(disc_number, 1), track_number) WHERE album_id IS NOT NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS groups_name_key_idx;
+ALTER TABLE groups DROP COLUMN IF EXISTS name_key;
@@ -0,0 +1,8 @@
+-- Ключ названия группы, как name_key у песен: без учёта регистра и лишних
+-- пробелов. По нему группа находится при добавлении песни по названию группы,
+-- поэтому написание влитого дубликата ведёт в группу, оставшуюся после
+-- объединения. Ключи вычисляет приложение, пустые ключи оно заполняет при запуске.
+-- Индекс не уникальный: дубликаты до объединения допустимы.
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS name_key TEXT;
+
+CREATE INDEX IF NOT EXISTS groups_name_key_idx ON groups (name_key);
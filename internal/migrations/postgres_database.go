@@ -0,0 +1,70 @@
+package migrations
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Ключ advisory lock, под которым выполняются миграции. Одновременно
+// запущенные экземпляры сервиса ждут друг друга, а не применяют миграции дважды.
+const advisoryLockID = 7_240_153_918
+
+type postgresDatabase struct {
+	pool *pgxpool.Pool
+}
+
+type postgresSession struct {
+	conn *pgx.Conn
+}
+
+// locked берёт advisory lock. Блокировка принадлежит сессии, поэтому
+// все запросы миграций идут через одно соединение из пула.
+func (d postgresDatabase) locked(ctx context.Context, fn func(conn session, applied map[int]time.Time) error) error {
+	pooled, err := d.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer pooled.Release()
+	conn := pooled.Conn()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", advisoryLockID); err != nil {
+		return err
+	}
+	defer conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", advisoryLockID)
+
+	_, err = conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`)
+	if err != nil {
+		return err
+	}
+
+	rows, err := conn.Query(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return err
+	}
+	applied, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (appliedVersion, error) {
+		var v appliedVersion
+		err := row.Scan(&v.version, &v.appliedAt)
+		return v, err
+	})
+	if err != nil {
+		return err
+	}
+
+	return fn(postgresSession{conn: conn}, appliedMap(applied))
+}
+
+func (s postgresSession) inTx(ctx context.Context, fn func(exec execFunc) error) error {
+	return pgx.BeginFunc(ctx, s.conn, func(tx pgx.Tx) error {
+		return fn(func(query string, args ...any) error {
+			_, err := tx.Exec(ctx, query, args...)
+			return err
+		})
+	})
+}
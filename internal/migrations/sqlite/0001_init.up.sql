@@ -0,0 +1,25 @@
+-- Базовая схема. Даты хранятся строками YYYY-MM-DD, время последней
+-- попытки обогащения — в секундах Unix.
+CREATE TABLE groups (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    group_name TEXT NOT NULL UNIQUE,
+    search_key TEXT
+);
+
+CREATE TABLE songs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    group_id INTEGER REFERENCES groups(id),
+    song_name TEXT NOT NULL UNIQUE,
+    search_key TEXT,
+    release_date DATE DEFAULT (date('now')),
+    text TEXT,
+    link TEXT,
+    needs_enrichment BOOLEAN NOT NULL DEFAULT FALSE,
+    enrich_attempts INTEGER NOT NULL DEFAULT 0,
+    enrich_last_error TEXT,
+    enrich_last_attempt INTEGER
+);
+
+CREATE INDEX songs_group_id_idx ON songs (group_id);
+CREATE INDEX songs_search_key_idx ON songs (search_key);
+CREATE INDEX groups_search_key_idx ON groups (search_key);
@@ -0,0 +1,6 @@
+DROP TRIGGER groups_fts_update;
+DROP TRIGGER songs_fts_delete;
+DROP TRIGGER songs_fts_update;
+DROP TRIGGER songs_fts_insert;
+DROP TABLE songs_fts_en;
+DROP TABLE songs_fts;
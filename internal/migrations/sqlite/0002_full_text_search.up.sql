@@ -0,0 +1,47 @@
+-- Полнотекстовый поиск FTS5 по названию, группе и тексту. songs_fts
+-- не изменяет слова и используется для русского, songs_fts_en приводит
+-- английские слова к основе стеммером Портера. rowid совпадает с id песни.
+CREATE VIRTUAL TABLE songs_fts USING fts5(
+    song_name, group_name, text,
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+CREATE VIRTUAL TABLE songs_fts_en USING fts5(
+    song_name, group_name, text,
+    tokenize = 'porter unicode61 remove_diacritics 2'
+);
+
+CREATE TRIGGER songs_fts_insert AFTER INSERT ON songs BEGIN
+    INSERT INTO songs_fts (rowid, song_name, group_name, text)
+    SELECT NEW.id, NEW.song_name, (SELECT group_name FROM groups WHERE id = NEW.group_id), NEW.text;
+    INSERT INTO songs_fts_en (rowid, song_name, group_name, text)
+    SELECT NEW.id, NEW.song_name, (SELECT group_name FROM groups WHERE id = NEW.group_id), NEW.text;
+END;
+
+CREATE TRIGGER songs_fts_update AFTER UPDATE OF song_name, group_id, text ON songs BEGIN
+    DELETE FROM songs_fts WHERE rowid = OLD.id;
+    DELETE FROM songs_fts_en WHERE rowid = OLD.id;
+    INSERT INTO songs_fts (rowid, song_name, group_name, text)
+    SELECT NEW.id, NEW.song_name, (SELECT group_name FROM groups WHERE id = NEW.group_id), NEW.text;
+    INSERT INTO songs_fts_en (rowid, song_name, group_name, text)
+    SELECT NEW.id, NEW.song_name, (SELECT group_name FROM groups WHERE id = NEW.group_id), NEW.text;
+END;
+
+CREATE TRIGGER songs_fts_delete AFTER DELETE ON songs BEGIN
+    DELETE FROM songs_fts WHERE rowid = OLD.id;
+    DELETE FROM songs_fts_en WHERE rowid = OLD.id;
+END;
+
+-- При переименовании группы обновляем индекс её песен
+CREATE TRIGGER groups_fts_update AFTER UPDATE OF group_name ON groups BEGIN
+    UPDATE songs_fts SET group_name = NEW.group_name
+    WHERE rowid IN (SELECT id FROM songs WHERE group_id = NEW.id);
+    UPDATE songs_fts_en SET group_name = NEW.group_name
+    WHERE rowid IN (SELECT id FROM songs WHERE group_id = NEW.id);
+END;
+
+-- Индексируем уже существующие песни
+INSERT INTO songs_fts (rowid, song_name, group_name, text)
+SELECT s.id, s.song_name, g.group_name, s.text FROM songs s LEFT JOIN groups g ON g.id = s.group_id;
+INSERT INTO songs_fts_en (rowid, song_name, group_name, text)
+SELECT s.id, s.song_name, g.group_name, s.text FROM songs s LEFT JOIN groups g ON g.id = s.group_id;
@@ -0,0 +1,13 @@
+DELETE FROM songs
+WHERE EXISTS (
+    SELECT 1 FROM groups g
+    WHERE g.id = songs.group_id
+        AND (g.group_name, songs.song_name) IN (VALUES
+            ('Imagine Dragons', 'Believer'),
+            ('Imagine Dragons', 'Thunder'),
+            ('Linkin Park', 'In the end')
+        )
+);
+DELETE FROM groups
+WHERE group_name IN ('Imagine Dragons', 'Linkin Park')
+    AND NOT EXISTS (SELECT 1 FROM songs s WHERE s.group_id = groups.id);
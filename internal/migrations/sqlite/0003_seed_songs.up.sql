@@ -0,0 +1,16 @@
+-- Начальные данные, те же, что и в Postgres
+INSERT INTO groups (group_name) VALUES
+('Imagine Dragons'),
+('Linkin Park')
+ON CONFLICT (group_name) DO NOTHING;
+
+INSERT INTO songs (group_id, song_name, text, link)
+SELECT g.id, v.song_name, v.text, v.link
+FROM (
+    SELECT 'Imagine Dragons' group_name, 'Believer' song_name, 'I am believer' text, 'https://youtu.be/7wtfhZwyrcc?si=AhOKtDFQw19Cmfmy' link
+    UNION ALL SELECT 'Imagine Dragons', 'Thunder', 'Before the thunder', 'https://youtu.be/fKopy74weus?si=PPbCVQS28w3Fp0Ga'
+    UNION ALL SELECT 'Linkin Park', 'In the end', 'It doens''t even matter', 'https://youtu.be/eVTXPUF4Oz4?si=XRrAbzJJqOO4jAJx'
+) v
+INNER JOIN groups g ON g.group_name = v.group_name
+WHERE TRUE
+ON CONFLICT (song_name) DO NOTHING;
@@ -0,0 +1,2 @@
+-- Версия строки для оптимистичной блокировки, как в Postgres
+ALTER TABLE songs ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,9 @@
+DROP INDEX songs_release_date_idx;
+
+UPDATE songs SET release_date = (
+    SELECT value FROM release_date_rejects r WHERE r.song_id = songs.id
+)
+WHERE release_date IS NULL AND id IN (SELECT song_id FROM release_date_rejects);
+
+ALTER TABLE songs DROP COLUMN release_date_precision;
+DROP TABLE release_date_rejects;
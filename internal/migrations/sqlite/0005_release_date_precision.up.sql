@@ -0,0 +1,42 @@
+-- Точность даты релиза и очистка дат, сохранённых не в формате YYYY-MM-DD.
+-- Нераспознанные значения переносятся в release_date_rejects, как в Postgres.
+CREATE TABLE release_date_rejects (
+    song_id INTEGER NOT NULL,
+    value TEXT NOT NULL,
+    rejected_at INTEGER NOT NULL DEFAULT (unixepoch())
+);
+
+ALTER TABLE songs ADD COLUMN release_date_precision TEXT NOT NULL DEFAULT 'day'
+    CHECK (release_date_precision IN ('day', 'month', 'year'));
+
+-- Дата с временем
+UPDATE songs SET release_date = substr(release_date, 1, 10)
+WHERE release_date GLOB '[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9]?*';
+
+-- DD.MM.YYYY из внешнего API
+UPDATE songs SET release_date = substr(release_date, 7, 4) || '-' || substr(release_date, 4, 2) || '-' || substr(release_date, 1, 2)
+WHERE release_date GLOB '[0-9][0-9].[0-9][0-9].[0-9][0-9][0-9][0-9]';
+
+UPDATE songs SET release_date = release_date || '-01', release_date_precision = 'month'
+WHERE release_date GLOB '[0-9][0-9][0-9][0-9]-[0-9][0-9]';
+
+-- MM.YYYY. У столбца DATE числовое родство, поэтому такая дата хранится
+-- числом: 03.2004 — 3.2004, 12.2010 — 12.201. printf возвращает ей исходный вид.
+UPDATE songs SET release_date = substr(printf('%07.4f', release_date), 4, 4) || '-'
+        || substr(printf('%07.4f', release_date), 1, 2) || '-01',
+    release_date_precision = 'month'
+WHERE typeof(release_date) = 'real' AND printf('%07.4f', release_date) GLOB '[0-9][0-9].[0-9][0-9][0-9][0-9]';
+
+UPDATE songs SET release_date = release_date || '-01-01', release_date_precision = 'year'
+WHERE release_date GLOB '[0-9][0-9][0-9][0-9]';
+
+-- date() нормализует несуществующие даты (2020-02-30), поэтому значение
+-- считается правильным, только если date() вернула его без изменений
+INSERT INTO release_date_rejects (song_id, value)
+SELECT id, release_date FROM songs
+WHERE release_date IS NOT NULL AND date(release_date) IS NOT release_date;
+
+UPDATE songs SET release_date = NULL, release_date_precision = 'day'
+WHERE release_date IS NOT NULL AND date(release_date) IS NOT release_date;
+
+CREATE INDEX songs_release_date_idx ON songs (release_date);
@@ -0,0 +1,60 @@
+-- Откат не пройдёт, если у разных групп уже есть песни с одинаковым названием
+DROP TRIGGER groups_fts_update;
+
+CREATE TABLE songs_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    group_id INTEGER REFERENCES groups(id),
+    song_name TEXT NOT NULL UNIQUE,
+    search_key TEXT,
+    release_date DATE DEFAULT (date('now')),
+    release_date_precision TEXT NOT NULL DEFAULT 'day'
+        CHECK (release_date_precision IN ('day', 'month', 'year')),
+    text TEXT,
+    link TEXT,
+    needs_enrichment BOOLEAN NOT NULL DEFAULT FALSE,
+    enrich_attempts INTEGER NOT NULL DEFAULT 0,
+    enrich_last_error TEXT,
+    enrich_last_attempt INTEGER,
+    version INTEGER NOT NULL DEFAULT 1
+);
+
+INSERT INTO songs_new (id, group_id, song_name, search_key, release_date, release_date_precision, text, link,
+    needs_enrichment, enrich_attempts, enrich_last_error, enrich_last_attempt, version)
+SELECT id, group_id, song_name, search_key, release_date, release_date_precision, text, link,
+    needs_enrichment, enrich_attempts, enrich_last_error, enrich_last_attempt, version
+FROM songs;
+
+DROP TABLE songs;
+ALTER TABLE songs_new RENAME TO songs;
+
+CREATE TRIGGER songs_fts_insert AFTER INSERT ON songs BEGIN
+    INSERT INTO songs_fts (rowid, song_name, group_name, text)
+    SELECT NEW.id, NEW.song_name, (SELECT group_name FROM groups WHERE id = NEW.group_id), NEW.text;
+    INSERT INTO songs_fts_en (rowid, song_name, group_name, text)
+    SELECT NEW.id, NEW.song_name, (SELECT group_name FROM groups WHERE id = NEW.group_id), NEW.text;
+END;
+
+CREATE TRIGGER songs_fts_update AFTER UPDATE OF song_name, group_id, text ON songs BEGIN
+    DELETE FROM songs_fts WHERE rowid = OLD.id;
+    DELETE FROM songs_fts_en WHERE rowid = OLD.id;
+    INSERT INTO songs_fts (rowid, song_name, group_name, text)
+    SELECT NEW.id, NEW.song_name, (SELECT group_name FROM groups WHERE id = NEW.group_id), NEW.text;
+    INSERT INTO songs_fts_en (rowid, song_name, group_name, text)
+    SELECT NEW.id, NEW.song_name, (SELECT group_name FROM groups WHERE id = NEW.group_id), NEW.text;
+END;
+
+CREATE TRIGGER songs_fts_delete AFTER DELETE ON songs BEGIN
+    DELETE FROM songs_fts WHERE rowid = OLD.id;
+    DELETE FROM songs_fts_en WHERE rowid = OLD.id;
+END;
+
+CREATE TRIGGER groups_fts_update AFTER UPDATE OF group_name ON groups BEGIN
+    UPDATE songs_fts SET group_name = NEW.group_name
+    WHERE rowid IN (SELECT id FROM songs WHERE group_id = NEW.id);
+    UPDATE songs_fts_en SET group_name = NEW.group_name
+    WHERE rowid IN (SELECT id FROM songs WHERE group_id = NEW.id);
+END;
+
+CREATE INDEX songs_group_id_idx ON songs (group_id);
+CREATE INDEX songs_search_key_idx ON songs (search_key);
+CREATE INDEX songs_release_date_idx ON songs (release_date);
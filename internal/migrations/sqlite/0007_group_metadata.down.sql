@@ -0,0 +1,4 @@
+ALTER TABLE groups DROP COLUMN bio;
+ALTER TABLE groups DROP COLUMN genres;
+ALTER TABLE groups DROP COLUMN formed_year;
+ALTER TABLE groups DROP COLUMN country;
@@ -0,0 +1,5 @@
+-- Описание групп, как в Postgres. Жанры хранятся массивом JSON в тексте.
+ALTER TABLE groups ADD COLUMN country TEXT;
+ALTER TABLE groups ADD COLUMN formed_year INTEGER;
+ALTER TABLE groups ADD COLUMN genres TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE groups ADD COLUMN bio TEXT;
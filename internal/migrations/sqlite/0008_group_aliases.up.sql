@@ -0,0 +1,7 @@
+-- ID групп, влитых в другую группу, как в Postgres
+CREATE TABLE group_aliases (
+    id INTEGER PRIMARY KEY,
+    group_id INTEGER NOT NULL REFERENCES groups (id) ON DELETE CASCADE
+);
+
+CREATE INDEX group_aliases_group_id_idx ON group_aliases (group_id);
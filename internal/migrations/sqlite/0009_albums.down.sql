@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS songs_album_track_idx;
+DROP INDEX IF EXISTS songs_album_id_idx;
+ALTER TABLE songs DROP COLUMN track_number;
+ALTER TABLE songs DROP COLUMN disc_number;
+ALTER TABLE songs DROP COLUMN album_id;
+DROP TABLE IF EXISTS albums;
@@ -0,0 +1,24 @@
+-- Альбомы и место песни в альбоме, как в Postgres
+CREATE TABLE albums (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    search_key TEXT,
+    group_id INTEGER REFERENCES groups (id) ON DELETE CASCADE,
+    release_date DATE,
+    release_date_precision TEXT NOT NULL DEFAULT 'day'
+        CHECK (release_date_precision IN ('day', 'month', 'year')),
+    album_type TEXT NOT NULL DEFAULT 'lp'
+        CHECK (album_type IN ('lp', 'ep', 'single', 'compilation')),
+    cover TEXT
+);
+
+CREATE INDEX albums_group_id_idx ON albums (group_id);
+CREATE INDEX albums_search_key_idx ON albums (search_key);
+
+ALTER TABLE songs ADD COLUMN album_id INTEGER REFERENCES albums (id) ON DELETE SET NULL;
+ALTER TABLE songs ADD COLUMN disc_number INTEGER CHECK (disc_number > 0);
+ALTER TABLE songs ADD COLUMN track_number INTEGER CHECK (track_number > 0);
+
+CREATE INDEX songs_album_id_idx ON songs (album_id);
+CREATE UNIQUE INDEX songs_album_track_idx
+    ON songs (album_id, COALESCE(disc_number, 1), track_number) WHERE album_id IS NOT NULL;
@@ -0,0 +1,4 @@
+-- Ключ названия группы, как в Postgres
+ALTER TABLE groups ADD COLUMN name_key TEXT;
+
+CREATE INDEX groups_name_key_idx ON groups (name_key);
@@ -0,0 +1,73 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type sqliteDatabase struct {
+	db *sql.DB
+}
+
+type sqliteSession struct {
+	conn *sql.Conn
+}
+
+// locked выполняет миграции через одно соединение. SQLite сам не пускает
+// двух писателей одновременно: если другой процесс уже применил миграцию,
+// транзакция с повторной записью в schema_migrations откатится.
+func (d sqliteDatabase) locked(ctx context.Context, fn func(conn session, applied map[int]time.Time) error) error {
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return err
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var applied []appliedVersion
+	for rows.Next() {
+		var v appliedVersion
+		if err := rows.Scan(&v.version, &v.appliedAt); err != nil {
+			return err
+		}
+		applied = append(applied, v)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return fn(sqliteSession{conn: conn}, appliedMap(applied))
+}
+
+func (s sqliteSession) inTx(ctx context.Context, fn func(exec execFunc) error) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = fn(func(query string, args ...any) error {
+		_, err := tx.ExecContext(ctx, query, args...)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,64 @@
+// Package searchkey строит поисковые ключи для названий групп и песен.
+//
+// Поисковый ключ не зависит от регистра, диакритики и алфавита: кириллица
+// транслитерируется в латиницу, поэтому «Кино» и «Kino» дают ключ "kino",
+// «Beyoncé» — "beyonce", а «Сплин!» и «splin» — "splin".
+package searchkey
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Транслитерация строчной кириллицы, близкая к тому, как названия
+// обычно пишут латиницей, и латинские буквы, которые не раскладываются
+// на букву и диакритический знак
+var toLatin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+	'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	// Украинские и белорусские буквы
+	'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g", 'ў': "u",
+	// Латиница
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'ł': "l", 'đ': "d", 'ð': "d", 'þ': "th",
+}
+
+// Key возвращает поисковый ключ: строчные латинские буквы и цифры,
+// слова разделены одним пробелом
+func Key(value string) string {
+	var translit strings.Builder
+	for _, r := range strings.ToLower(value) {
+		if latin, ok := toLatin[r]; ok {
+			translit.WriteString(latin)
+			continue
+		}
+		translit.WriteRune(r)
+	}
+
+	// Раскладываем буквы с диакритикой и отбрасываем знаки: é -> e
+	stripMarks := transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	plain, _, err := transform.String(stripMarks, translit.String())
+	if err != nil {
+		plain = translit.String()
+	}
+
+	// Знаки препинания и пробелы схлопываем в один разделитель
+	words := strings.FieldsFunc(plain, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	return strings.Join(words, " ")
+}
+
+// NameKey возвращает ключ уникальности названия: без учёта регистра
+// и лишних пробелов, но с сохранением алфавита, диакритики и знаков,
+// поэтому «Believer» и « believer » совпадают, а «Кино» и «Kino» — нет
+func NameKey(value string) string {
+	return strings.Join(strings.Fields(strings.ToLower(norm.NFC.String(value))), " ")
+}
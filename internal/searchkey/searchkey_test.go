@@ -0,0 +1,59 @@
+package searchkey_test
+
+import (
+	"effectiveMobile/internal/searchkey"
+	"testing"
+)
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"кириллица и латиница", "Кино", "Kino", "kino"},
+		{"знаки препинания", "Сплин!", "splin", "splin"},
+		{"диакритика", "Beyoncé", "beyonce", "beyonce"},
+		{"ё и е", "Ёлка", "Елка", "elka"},
+		{"регистр", "LINKIN park", "Linkin Park", "linkin park"},
+		{"лишние пробелы", "  Linkin   Park ", "linkin park", "linkin park"},
+		{"цифры", "Blink-182", "blink 182", "blink 182"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := searchkey.Key(tt.a); got != tt.want {
+				t.Errorf("Key(%q) = %q, ожидался %q", tt.a, got, tt.want)
+			}
+			if got := searchkey.Key(tt.b); got != tt.want {
+				t.Errorf("Key(%q) = %q, ожидался %q", tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNameKey(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		same bool
+	}{
+		{"регистр", "Believer", "BELIEVER", true},
+		{"лишние пробелы", " Linkin  Park ", "linkin park", true},
+		{"кириллица и латиница", "Кино", "Kino", false},
+		{"диакритика", "Beyoncé", "beyonce", false},
+		{"знаки препинания", "Сплин!", "сплин", false},
+		{"ё и е", "Ёлка", "Елка", false},
+		// NFC и NFD записи одной буквы совпадают
+		{"нормализация", "Beyonc\u00e9", "Beyonce\u0301", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := searchkey.NameKey(tt.a), searchkey.NameKey(tt.b)
+			if (a == b) != tt.same {
+				t.Errorf("NameKey(%q) = %q, NameKey(%q) = %q, совпадение ожидалось: %v", tt.a, a, tt.b, b, tt.same)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"context"
+	"effectiveMobile/internal/searchkey"
+	"effectiveMobile/models"
+	"fmt"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+)
+
+// AlbumStorage хранит альбомы. Песня попадает в альбом через PatchSong.
+type AlbumStorage interface {
+	GetAllAlbums(ctx context.Context, filter models.AlbumFilter, page models.Pagination) (models.AlbumPage, error)
+	GetAlbumByID(ctx context.Context, id int) (models.Album, error)
+	// GetAlbumTracks возвращает песни альбома по порядку дисков и номеров
+	// треков, песни без номера идут в конце диска
+	GetAlbumTracks(ctx context.Context, id int) ([]models.Song, error)
+	// CreateAlbum возвращает ErrNotFound, если группы album.Group нет
+	CreateAlbum(ctx context.Context, album models.Album) (int, error)
+	UpdateAlbum(ctx context.Context, id int, album models.Album) error
+	// DeleteAlbum удаляет альбом. Его песни остаются без альбома и без
+	// номеров дисков и треков, их версии увеличиваются.
+	DeleteAlbum(ctx context.Context, id int) error
+}
+
+// albumColumns — столбцы альбома в порядке полей models.Album, общие для Postgres и SQLite
+const albumColumns = `a.id, a.title, a.group_id, g.group_name, a.release_date, a.release_date_precision,
+	a.album_type, a.cover, (SELECT COUNT(*) FROM songs s WHERE s.album_id = a.id) track_count`
+
+// albumFrom — альбомы вместе с группой; у сборника группы может не быть
+const albumFrom = `FROM albums a LEFT JOIN groups g ON g.id = a.group_id`
+
+// albumOrder — альбомы по дате релиза, альбомы без даты в конце
+const albumOrder = `ORDER BY a.release_date IS NULL, a.release_date, a.title, a.id`
+
+// albumTracksQuery выбирает песни альбома в порядке треков
+const albumTracksQuery = `SELECT s.id, song_name name, group_name, release_date, release_date_precision, link,
+	album_id, disc_number, track_number
+	FROM songs s
+	INNER JOIN groups g ON g.id = s.group_id
+	WHERE s.album_id = $1
+	ORDER BY COALESCE(s.disc_number, 1), s.track_number IS NULL, s.track_number, s.id`
+
+// detachAlbumSongsQuery убирает песни из альбома перед его удалением.
+// ON DELETE SET NULL оставил бы номера треков и не изменил бы версии песен.
+const detachAlbumSongsQuery = `UPDATE songs SET album_id = NULL, disc_number = NULL, track_number = NULL,
+	version = version + 1
+	WHERE album_id = $1`
+
+// buildAlbumWhere переводит фильтр альбомов в условие над albums a
+func buildAlbumWhere(filter models.AlbumFilter, ilike likeFunc) *whereBuilder {
+	b := &whereBuilder{ilike: ilike}
+
+	if filter.Title != "" {
+		b.add(matchCondition("a.search_key", models.MatchContains, searchkey.Key(filter.Title), b))
+	}
+	if filter.GroupID != nil {
+		b.add("a.group_id = " + b.arg(*filter.GroupID))
+	}
+	if filter.Type != "" {
+		b.add("a.album_type = " + b.arg(string(filter.Type)))
+	}
+
+	return b
+}
+
+func (s *songStorage) GetAllAlbums(ctx context.Context, filter models.AlbumFilter, page models.Pagination) (models.AlbumPage, error) {
+	s.infoLog.Print("Запускаем SQL запрос по получению альбомов")
+	result := models.AlbumPage{Items: []models.Album{}}
+
+	b := buildAlbumWhere(filter, postgresILike)
+
+	err := s.db.QueryRow(ctx, "SELECT COUNT(*) FROM albums a "+b.sql(), b.args...).Scan(&result.Total)
+	if err != nil {
+		s.errorLog.Println(err)
+		return result, err
+	}
+
+	query := fmt.Sprintf(`SELECT %s
+	%s
+	%s
+	%s
+	LIMIT %s OFFSET %s`, albumColumns, albumFrom, b.sql(), albumOrder, b.arg(page.Limit), b.arg((page.Page-1)*page.Limit))
+
+	err = pgxscan.Select(ctx, s.db, &result.Items, query, b.args...)
+	if err != nil {
+		s.errorLog.Println(err)
+	}
+
+	return result, err
+}
+
+func (s *songStorage) GetAlbumByID(ctx context.Context, id int) (models.Album, error) {
+	s.infoLog.Print("Запускаем SQL запрос по получению альбома по ID")
+	query := `SELECT ` + albumColumns + ` ` + albumFrom + ` WHERE a.id = $1`
+
+	var album models.Album
+	err := pgxscan.Get(ctx, s.db, &album, query, id)
+	if pgxscan.NotFound(err) {
+		return album, ErrNotFound
+	}
+	if err != nil {
+		s.errorLog.Println(err)
+	}
+
+	return album, err
+}
+
+func (s *songStorage) GetAlbumTracks(ctx context.Context, id int) ([]models.Song, error) {
+	s.infoLog.Print("Запускаем SQL запрос по получению песен альбома")
+
+	tracks := []models.Song{}
+	err := pgxscan.Select(ctx, s.db, &tracks, albumTracksQuery, id)
+	if err != nil {
+		s.errorLog.Println(err)
+	}
+
+	return tracks, err
+}
+
+func (s *songStorage) CreateAlbum(ctx context.Context, album models.Album) (int, error) {
+	s.infoLog.Print("Запускаем SQL запрос по созданию альбома")
+	query := `INSERT INTO albums (title, search_key, group_id, release_date, release_date_precision, album_type, cover)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING id`
+
+	var id int
+	err := s.db.QueryRow(ctx, query,
+		album.Title, searchKey(album.Title), album.Group, dateString(album.ReleaseDate),
+		releasePrecision(album.ReleaseDate, album.ReleaseDatePrecision), string(album.Type), album.Cover,
+	).Scan(&id)
+	if err != nil {
+		s.errorLog.Println(err)
+	}
+	return id, mapPgError(err)
+}
+
+func (s *songStorage) UpdateAlbum(ctx context.Context, id int, album models.Album) error {
+	s.infoLog.Print("Запускаем SQL запрос по обновлению альбома")
+	query := `UPDATE albums SET title = $1, search_key = $2, group_id = $3, release_date = $4,
+		release_date_precision = $5, album_type = $6, cover = $7
+	WHERE id = $8`
+
+	tag, err := s.db.Exec(ctx, query,
+		album.Title, searchKey(album.Title), album.Group, dateString(album.ReleaseDate),
+		releasePrecision(album.ReleaseDate, album.ReleaseDatePrecision), string(album.Type), album.Cover, id)
+	if err != nil {
+		s.errorLog.Println(err)
+		return mapPgError(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *songStorage) DeleteAlbum(ctx context.Context, id int) error {
+	s.infoLog.Print("Запускаем SQL запрос по удалению альбома")
+
+	return s.inTx(ctx, func(tx *songStorage) error {
+		if _, err := tx.db.Exec(ctx, detachAlbumSongsQuery, id); err != nil {
+			tx.errorLog.Println(err)
+			return err
+		}
+
+		tag, err := tx.db.Exec(ctx, "DELETE FROM albums WHERE id = $1", id)
+		if err != nil {
+			tx.errorLog.Println(err)
+			return err
+		}
+
+		if tag.RowsAffected() == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	ErrNotFound = errors.New("запись не найдена")
+	ErrConflict = errors.New("запись уже существует")
+	// ErrVersionMismatch означает, что запись изменили после того,
+	// как клиент прочитал ожидаемую версию
+	ErrVersionMismatch = errors.New("версия записи изменилась")
+	// ErrGroupNotEmpty означает, что у удаляемой группы остались песни
+	ErrGroupNotEmpty = errors.New("у группы есть песни")
+)
+
+// ConflictError — ErrConflict с ID записи, которая уже существует
+type ConflictError struct {
+	ID int
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%v: id %d", ErrConflict, e.ID)
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// DuplicateNamesError — песни, добавленные в обход приложения, название
+// которых совпало с другой песней той же группы. Ключа уникальности у них
+// нет, пока их не переименуют или не удалят.
+type DuplicateNamesError struct {
+	IDs []int
+}
+
+func (e *DuplicateNamesError) Error() string {
+	return fmt.Sprintf("%v: песни %v повторяют названия других песен своих групп", ErrConflict, e.IDs)
+}
+
+func (e *DuplicateNamesError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// Коды ошибок Postgres
+const (
+	pgForeignKeyViolation = "23503"
+	pgUniqueViolation     = "23505"
+)
+
+// mapPgError переводит нарушения ограничений Postgres в ошибки хранилища,
+// остальные ошибки возвращает без изменений
+func mapPgError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	switch pgErr.Code {
+	case pgUniqueViolation:
+		return ErrConflict
+	case pgForeignKeyViolation:
+		return ErrNotFound
+	}
+
+	return err
+}
@@ -0,0 +1,264 @@
+package storage
+
+import (
+	"context"
+	"effectiveMobile/internal/searchkey"
+	"effectiveMobile/models"
+	"errors"
+	"fmt"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+)
+
+// GroupStorage хранит группы (артистов) и их описание
+type GroupStorage interface {
+	GetAllGroups(ctx context.Context, filter models.GroupFilter, page models.Pagination) (models.GroupPage, error)
+	GetGroupByID(ctx context.Context, id int) (models.Group, error)
+	// CreateGroup возвращает *ConflictError, если группа с таким названием уже есть
+	CreateGroup(ctx context.Context, group models.Group) (int, error)
+	UpdateGroup(ctx context.Context, id int, group models.Group) error
+	// DeleteGroup удаляет группу без песен вместе с её альбомами. С cascade
+	// вместе с группой удаляются её песни, иначе для группы с песнями
+	// возвращается ErrGroupNotEmpty.
+	DeleteGroup(ctx context.Context, id int, cascade bool) error
+	// AliasGroup удаляет группу id без песен и оставляет её ID псевдонимом
+	// группы targetID. Альбомы и псевдонимы группы id переходят к targetID.
+	AliasGroup(ctx context.Context, id, targetID int) error
+	// ResolveGroupAlias возвращает ID группы, в которую влита группа id,
+	// или ErrNotFound, если id не псевдоним
+	ResolveGroupAlias(ctx context.Context, id int) (int, error)
+}
+
+// groupColumns — столбцы группы в порядке полей models.Group, общие для Postgres и SQLite
+const groupColumns = `g.id, g.group_name, g.country, g.formed_year, g.genres, g.bio,
+	(SELECT COUNT(*) FROM songs s WHERE s.group_id = g.id) song_count`
+
+// existingGroupQuery ищет группу с тем же названием, кроме группы $2
+const existingGroupQuery = "SELECT id FROM groups WHERE group_name = $1 AND id <> $2"
+
+// touchGroupSongsQuery увеличивает версии песен группы: название группы
+// входит в представление песни, и ETag должен измениться вместе с ним
+const touchGroupSongsQuery = "UPDATE songs SET version = version + 1 WHERE group_id = $1"
+
+// detachGroupAlbumsQuery, как detachAlbumSongsQuery, убирает песни
+// из альбомов группы до того, как внешний ключ удалит альбомы вместе с ней
+const detachGroupAlbumsQuery = `UPDATE songs SET album_id = NULL, disc_number = NULL, track_number = NULL,
+	version = version + 1
+	WHERE album_id IN (SELECT id FROM albums WHERE group_id = $1)`
+
+// buildGroupWhere переводит фильтр групп в условие над groups g.
+// hasGenre строит проверку того, что массив JSON содержит жанр.
+func buildGroupWhere(filter models.GroupFilter, ilike likeFunc, hasGenre func(genre string) string) *whereBuilder {
+	b := &whereBuilder{ilike: ilike}
+
+	if filter.Name != "" {
+		b.add(matchCondition("g.search_key", models.MatchContains, searchkey.Key(filter.Name), b))
+	}
+	if filter.Genre != "" {
+		b.add(hasGenre(b.arg(filter.Genre)))
+	}
+
+	return b
+}
+
+func (s *songStorage) GetAllGroups(ctx context.Context, filter models.GroupFilter, page models.Pagination) (models.GroupPage, error) {
+	s.infoLog.Print("Запускаем SQL запрос по получению групп")
+	result := models.GroupPage{Items: []models.Group{}}
+
+	b := buildGroupWhere(filter, postgresILike, func(genre string) string {
+		return fmt.Sprintf("g.genres @> jsonb_build_array(%s::text)", genre)
+	})
+
+	err := s.db.QueryRow(ctx, "SELECT COUNT(*) FROM groups g "+b.sql(), b.args...).Scan(&result.Total)
+	if err != nil {
+		s.errorLog.Println(err)
+		return result, err
+	}
+
+	query := fmt.Sprintf(`SELECT %s
+	FROM groups g
+	%s
+	ORDER BY g.group_name, g.id
+	LIMIT %s OFFSET %s`, groupColumns, b.sql(), b.arg(page.Limit), b.arg((page.Page-1)*page.Limit))
+
+	err = pgxscan.Select(ctx, s.db, &result.Items, query, b.args...)
+	if err != nil {
+		s.errorLog.Println(err)
+	}
+
+	return result, err
+}
+
+func (s *songStorage) GetGroupByID(ctx context.Context, id int) (models.Group, error) {
+	s.infoLog.Print("Запускаем SQL запрос по получению группы по ID")
+	query := `SELECT ` + groupColumns + ` FROM groups g WHERE g.id = $1`
+
+	var group models.Group
+	err := pgxscan.Get(ctx, s.db, &group, query, id)
+	if pgxscan.NotFound(err) {
+		return group, ErrNotFound
+	}
+	if err != nil {
+		s.errorLog.Println(err)
+	}
+
+	return group, err
+}
+
+// CreateGroup добавляет группу с описанием. В отличие от AddGroup
+// существующая группа считается конфликтом.
+func (s *songStorage) CreateGroup(ctx context.Context, group models.Group) (int, error) {
+	s.infoLog.Print("Запускаем SQL запрос по созданию группы")
+	query := `INSERT INTO groups (group_name, search_key, name_key, country, formed_year, genres, bio)
+	VALUES ($1, $2, $7, $3, $4, $5::jsonb, $6)
+	ON CONFLICT (group_name) DO NOTHING
+	RETURNING id`
+
+	var id int
+	err := s.db.QueryRow(ctx, query,
+		group.Name, searchKey(group.Name), group.Country, group.FormedYear, group.Genres.JSON(), group.Bio, nameKey(group.Name),
+	).Scan(&id)
+	if pgxscan.NotFound(err) {
+		if err := s.groupConflict(ctx, group.Name, 0); err != nil {
+			return 0, err
+		}
+		// Группу успели удалить
+		return 0, ErrConflict
+	}
+	if err != nil {
+		s.errorLog.Println(err)
+	}
+	return id, err
+}
+
+// groupConflict находит другую группу с названием name.
+// Если её нет, возвращает nil.
+func (s *songStorage) groupConflict(ctx context.Context, name *string, exceptID int) error {
+	var id int
+	err := s.db.QueryRow(ctx, existingGroupQuery, name, exceptID).Scan(&id)
+	if pgxscan.NotFound(err) {
+		return nil
+	}
+	if err != nil {
+		s.errorLog.Println(err)
+		return err
+	}
+	return &ConflictError{ID: id}
+}
+
+// UpdateGroup заменяет название и описание группы и в той же транзакции
+// увеличивает версии её песен. Занятое название проверяется до изменения,
+// чтобы не прерывать транзакцию ошибкой ограничения.
+func (s *songStorage) UpdateGroup(ctx context.Context, id int, group models.Group) error {
+	s.infoLog.Print("Запускаем SQL запрос по обновлению группы")
+
+	return s.inTx(ctx, func(tx *songStorage) error {
+		if err := tx.groupConflict(ctx, group.Name, id); err != nil {
+			return err
+		}
+
+		query := `UPDATE groups SET group_name = $1, search_key = $2, country = $3, formed_year = $4,
+			genres = $5::jsonb, bio = $6, name_key = $8
+		WHERE id = $7`
+
+		tag, err := tx.db.Exec(ctx, query,
+			group.Name, searchKey(group.Name), group.Country, group.FormedYear, group.Genres.JSON(), group.Bio, id, nameKey(group.Name))
+		if err != nil {
+			tx.errorLog.Println(err)
+			return mapPgError(err)
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrNotFound
+		}
+
+		if _, err := tx.db.Exec(ctx, touchGroupSongsQuery, id); err != nil {
+			tx.errorLog.Println(err)
+			return err
+		}
+		return nil
+	})
+}
+
+func (s *songStorage) DeleteGroup(ctx context.Context, id int, cascade bool) error {
+	s.infoLog.Print("Запускаем SQL запрос по удалению группы")
+
+	return s.inTx(ctx, func(tx *songStorage) error {
+		if cascade {
+			if _, err := tx.db.Exec(ctx, "DELETE FROM songs WHERE group_id = $1", id); err != nil {
+				tx.errorLog.Println(err)
+				return err
+			}
+		}
+		if _, err := tx.db.Exec(ctx, detachGroupAlbumsQuery, id); err != nil {
+			tx.errorLog.Println(err)
+			return err
+		}
+
+		query := `DELETE FROM groups WHERE id = $1
+			AND NOT EXISTS (SELECT 1 FROM songs WHERE group_id = $1)`
+		tag, err := tx.db.Exec(ctx, query, id)
+		if errors.Is(mapPgError(err), ErrNotFound) {
+			// Песню группы добавили параллельно, и сработал внешний ключ
+			return ErrGroupNotEmpty
+		}
+		if err != nil {
+			tx.errorLog.Println(err)
+			return err
+		}
+
+		if tag.RowsAffected() == 0 {
+			return tx.groupNotDeleted(ctx, id)
+		}
+		return nil
+	})
+}
+
+// groupNotDeleted объясняет, почему группа не удалена: её нет или у неё есть песни
+func (s *songStorage) groupNotDeleted(ctx context.Context, id int) error {
+	var exists bool
+	err := s.db.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM groups WHERE id = $1)", id).Scan(&exists)
+	if err != nil {
+		s.errorLog.Println(err)
+		return err
+	}
+
+	if exists {
+		return ErrGroupNotEmpty
+	}
+	return ErrNotFound
+}
+
+func (s *songStorage) AliasGroup(ctx context.Context, id, targetID int) error {
+	s.infoLog.Print("Запускаем SQL запрос по замене группы псевдонимом")
+
+	return s.inTx(ctx, func(tx *songStorage) error {
+		// Альбомы и псевдонимы переносятся до удаления группы, иначе их удалит внешний ключ
+		_, err := tx.db.Exec(ctx, "UPDATE albums SET group_id = $2 WHERE group_id = $1", id, targetID)
+		if err == nil {
+			_, err = tx.db.Exec(ctx, "UPDATE group_aliases SET group_id = $2 WHERE group_id = $1", id, targetID)
+		}
+		if err == nil {
+			_, err = tx.db.Exec(ctx, "INSERT INTO group_aliases (id, group_id) VALUES ($1, $2)", id, targetID)
+		}
+		if err != nil {
+			tx.errorLog.Println(err)
+			return mapPgError(err)
+		}
+
+		return tx.DeleteGroup(ctx, id, false)
+	})
+}
+
+func (s *songStorage) ResolveGroupAlias(ctx context.Context, id int) (int, error) {
+	s.infoLog.Print("Запускаем SQL запрос по поиску псевдонима группы")
+
+	var groupID int
+	err := s.db.QueryRow(ctx, "SELECT group_id FROM group_aliases WHERE id = $1", id).Scan(&groupID)
+	if pgxscan.NotFound(err) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		s.errorLog.Println(err)
+	}
+	return groupID, err
+}
@@ -0,0 +1,264 @@
+package storage
+
+import (
+	"cmp"
+	"context"
+	"effectiveMobile/internal/searchkey"
+	"effectiveMobile/models"
+	"errors"
+	"slices"
+	"sort"
+)
+
+var errAlbumTitleRequired = errors.New("название альбома обязательно")
+
+type memoryAlbum struct {
+	id          int
+	title       string
+	key         string
+	groupID     *int
+	releaseDate *models.Date
+	precision   models.DatePrecision
+	albumType   models.AlbumType
+	cover       *string
+}
+
+// album возвращает альбом песни, если он задан
+func (s *memoryStorage) album(id *int) *memoryAlbum {
+	if id == nil {
+		return nil
+	}
+	return s.albums[*id]
+}
+
+// toAlbumModel собирает альбом с названием группы и числом песен
+func (s *memoryStorage) toAlbumModel(album *memoryAlbum) models.Album {
+	title := album.title
+	result := models.Album{
+		ID:                   album.id,
+		Title:                &title,
+		Group:                copyInt(album.groupID),
+		ReleaseDate:          copyDate(album.releaseDate),
+		ReleaseDatePrecision: album.precision,
+		Type:                 album.albumType,
+		Cover:                copyString(album.cover),
+	}
+
+	if group, ok := s.group(album.groupID); ok {
+		groupName := group.name
+		result.GroupName = &groupName
+	}
+	for _, song := range s.songs {
+		if song.albumID != nil && *song.albumID == album.id {
+			result.TrackCount++
+		}
+	}
+
+	return result
+}
+
+func (s *memoryStorage) GetAllAlbums(ctx context.Context, filter models.AlbumFilter, page models.Pagination) (models.AlbumPage, error) {
+	s.infoLog.Print("Получаем альбомы из памяти")
+	result := models.AlbumPage{Items: []models.Album{}}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key := searchkey.Key(filter.Title)
+	var matched []*memoryAlbum
+	for _, album := range s.albums {
+		if filter.Title != "" && !matchKey(album.key, models.MatchContains, key) {
+			continue
+		}
+		if filter.GroupID != nil && (album.groupID == nil || *album.groupID != *filter.GroupID) {
+			continue
+		}
+		if filter.Type != "" && album.albumType != filter.Type {
+			continue
+		}
+		matched = append(matched, album)
+	}
+
+	// Как albumOrder: по дате релиза, альбомы без даты в конце
+	sort.Slice(matched, func(i, j int) bool {
+		a, b := matched[i], matched[j]
+		if (a.releaseDate == nil) != (b.releaseDate == nil) {
+			return b.releaseDate == nil
+		}
+		if a.releaseDate != nil && !a.releaseDate.Equal(b.releaseDate.Time) {
+			return a.releaseDate.Before(b.releaseDate.Time)
+		}
+		if a.title != b.title {
+			return a.title < b.title
+		}
+		return a.id < b.id
+	})
+
+	result.Total = len(matched)
+	start := min((page.Page-1)*page.Limit, len(matched))
+	end := min(start+page.Limit, len(matched))
+	for _, album := range matched[start:end] {
+		result.Items = append(result.Items, s.toAlbumModel(album))
+	}
+
+	return result, nil
+}
+
+func (s *memoryStorage) GetAlbumByID(ctx context.Context, id int) (models.Album, error) {
+	s.infoLog.Print("Получаем альбом по ID из памяти")
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	album, ok := s.albums[id]
+	if !ok {
+		return models.Album{}, ErrNotFound
+	}
+	return s.toAlbumModel(album), nil
+}
+
+func (s *memoryStorage) GetAlbumTracks(ctx context.Context, id int) ([]models.Song, error) {
+	s.infoLog.Print("Получаем песни альбома из памяти")
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var songs []*memorySong
+	for _, song := range s.songs {
+		if _, ok := s.group(song.groupID); ok && song.albumID != nil && *song.albumID == id {
+			songs = append(songs, song)
+		}
+	}
+
+	// Как albumTracksQuery: диск без номера считается первым,
+	// песни без номера трека идут в конце диска
+	slices.SortFunc(songs, func(a, b *memorySong) int {
+		if c := cmp.Compare(intOr(a.discNumber, 1), intOr(b.discNumber, 1)); c != 0 {
+			return c
+		}
+		if (a.trackNumber == nil) != (b.trackNumber == nil) {
+			if a.trackNumber == nil {
+				return 1
+			}
+			return -1
+		}
+		if c := cmp.Compare(intOr(a.trackNumber, 0), intOr(b.trackNumber, 0)); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.id, b.id)
+	})
+
+	tracks := []models.Song{}
+	for _, song := range songs {
+		tracks = append(tracks, s.toModel(song, false))
+	}
+	return tracks, nil
+}
+
+func intOr(value *int, fallback int) int {
+	if value == nil {
+		return fallback
+	}
+	return *value
+}
+
+func (s *memoryStorage) CreateAlbum(ctx context.Context, album models.Album) (int, error) {
+	s.infoLog.Print("Создаём альбом в памяти")
+
+	if album.Title == nil {
+		return 0, errAlbumTitleRequired
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if album.Group != nil {
+		if _, ok := s.groups[*album.Group]; !ok {
+			return 0, ErrNotFound
+		}
+	}
+
+	id := s.nextAlbumID
+	s.albums[id] = &memoryAlbum{id: id}
+	setAlbum(s.albums[id], album)
+	s.nextAlbumID++
+
+	return id, nil
+}
+
+// setAlbum записывает в альбом его поля
+func setAlbum(target *memoryAlbum, album models.Album) {
+	target.title = *album.Title
+	target.key = searchkey.Key(*album.Title)
+	target.groupID = copyInt(album.Group)
+	target.releaseDate = copyDate(album.ReleaseDate)
+	target.precision = releasePrecision(album.ReleaseDate, album.ReleaseDatePrecision)
+	target.albumType = album.Type
+	target.cover = copyString(album.Cover)
+}
+
+func (s *memoryStorage) UpdateAlbum(ctx context.Context, id int, album models.Album) error {
+	s.infoLog.Print("Обновляем альбом в памяти")
+
+	if album.Title == nil {
+		return errAlbumTitleRequired
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	target, ok := s.albums[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if album.Group != nil {
+		if _, ok := s.groups[*album.Group]; !ok {
+			return ErrNotFound
+		}
+	}
+
+	setAlbum(target, album)
+	return nil
+}
+
+func (s *memoryStorage) DeleteAlbum(ctx context.Context, id int) error {
+	s.infoLog.Print("Удаляем альбом из памяти")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.albums[id]; !ok {
+		return ErrNotFound
+	}
+	s.deleteAlbum(id)
+
+	return nil
+}
+
+// deleteAlbum удаляет альбом и, как detachAlbumSongsQuery, убирает
+// его у песен вместе с номерами дисков и треков
+func (s *memoryStorage) deleteAlbum(id int) {
+	for _, song := range s.songs {
+		if song.albumID != nil && *song.albumID == id {
+			song.albumID, song.discNumber, song.trackNumber = nil, nil, nil
+			song.version++
+		}
+	}
+	delete(s.albums, id)
+}
+
+// trackTaken сообщает, занято ли место в альбоме другой песней,
+// как уникальный индекс songs_album_track_idx
+func (s *memoryStorage) trackTaken(albumID, disc, track *int, exceptID int) bool {
+	if albumID == nil || track == nil {
+		return false
+	}
+
+	for _, song := range s.songs {
+		if song.id != exceptID && song.albumID != nil && *song.albumID == *albumID &&
+			song.trackNumber != nil && *song.trackNumber == *track && intOr(song.discNumber, 1) == intOr(disc, 1) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,235 @@
+package storage
+
+import (
+	"context"
+	"effectiveMobile/internal/searchkey"
+	"effectiveMobile/models"
+	"slices"
+	"sort"
+)
+
+// toGroupModel собирает группу с числом её песен
+func (s *memoryStorage) toGroupModel(group *memoryGroup) models.Group {
+	name := group.name
+	result := models.Group{
+		ID:         group.id,
+		Name:       &name,
+		Country:    copyString(group.country),
+		FormedYear: copyInt(group.formedYear),
+		Genres:     append(models.Genres{}, group.genres...),
+		Bio:        copyString(group.bio),
+	}
+
+	for _, song := range s.songs {
+		if song.groupID != nil && *song.groupID == group.id {
+			result.SongCount++
+		}
+	}
+
+	return result
+}
+
+func (s *memoryStorage) GetAllGroups(ctx context.Context, filter models.GroupFilter, page models.Pagination) (models.GroupPage, error) {
+	s.infoLog.Print("Получаем группы из памяти")
+	result := models.GroupPage{Items: []models.Group{}}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key := searchkey.Key(filter.Name)
+	var matched []*memoryGroup
+	for _, group := range s.groups {
+		if filter.Name != "" && !matchKey(group.key, models.MatchContains, key) {
+			continue
+		}
+		if filter.Genre != "" && !slices.Contains(group.genres, filter.Genre) {
+			continue
+		}
+		matched = append(matched, group)
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i].name != matched[j].name {
+			return matched[i].name < matched[j].name
+		}
+		return matched[i].id < matched[j].id
+	})
+
+	result.Total = len(matched)
+	start := min((page.Page-1)*page.Limit, len(matched))
+	end := min(start+page.Limit, len(matched))
+	for _, group := range matched[start:end] {
+		result.Items = append(result.Items, s.toGroupModel(group))
+	}
+
+	return result, nil
+}
+
+func (s *memoryStorage) GetGroupByID(ctx context.Context, id int) (models.Group, error) {
+	s.infoLog.Print("Получаем группу по ID из памяти")
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	group, ok := s.groups[id]
+	if !ok {
+		return models.Group{}, ErrNotFound
+	}
+	return s.toGroupModel(group), nil
+}
+
+// groupNamed ищет другую группу с названием name, как ограничение UNIQUE
+func (s *memoryStorage) groupNamed(name string, exceptID int) (int, bool) {
+	for _, group := range s.groups {
+		if group.name == name && group.id != exceptID {
+			return group.id, true
+		}
+	}
+	return 0, false
+}
+
+func (s *memoryStorage) CreateGroup(ctx context.Context, group models.Group) (int, error) {
+	s.infoLog.Print("Создаём группу в памяти")
+
+	if group.Name == nil {
+		return 0, errGroupNameRequired
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, taken := s.groupNamed(*group.Name, 0); taken {
+		return 0, &ConflictError{ID: existing}
+	}
+
+	id := s.nextGroupID
+	s.groups[id] = &memoryGroup{id: id}
+	s.setGroup(s.groups[id], group)
+	s.nextGroupID++
+
+	return id, nil
+}
+
+// setGroup записывает в группу название и описание
+func (s *memoryStorage) setGroup(target *memoryGroup, group models.Group) {
+	target.name = *group.Name
+	target.key = searchkey.Key(*group.Name)
+	target.country = copyString(group.Country)
+	target.formedYear = copyInt(group.FormedYear)
+	target.genres = append([]string{}, group.Genres...)
+	target.bio = copyString(group.Bio)
+}
+
+func (s *memoryStorage) UpdateGroup(ctx context.Context, id int, group models.Group) error {
+	s.infoLog.Print("Обновляем группу в памяти")
+
+	if group.Name == nil {
+		return errGroupNameRequired
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	target, ok := s.groups[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if existing, taken := s.groupNamed(*group.Name, id); taken {
+		return &ConflictError{ID: existing}
+	}
+
+	s.setGroup(target, group)
+	// Как touchGroupSongsQuery: название группы входит в представление песни
+	for _, song := range s.songs {
+		if song.groupID != nil && *song.groupID == id {
+			song.version++
+		}
+	}
+	return nil
+}
+
+func (s *memoryStorage) DeleteGroup(ctx context.Context, id int, cascade bool) error {
+	s.infoLog.Print("Удаляем группу из памяти")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.groups[id]; !ok {
+		return ErrNotFound
+	}
+
+	var songs []int
+	for _, song := range s.songs {
+		if song.groupID != nil && *song.groupID == id {
+			songs = append(songs, song.id)
+		}
+	}
+	if len(songs) > 0 && !cascade {
+		return ErrGroupNotEmpty
+	}
+
+	for _, songID := range songs {
+		delete(s.songs, songID)
+	}
+	delete(s.groups, id)
+	// Как ON DELETE CASCADE у albums и group_aliases
+	for albumID, album := range s.albums {
+		if album.groupID != nil && *album.groupID == id {
+			s.deleteAlbum(albumID)
+		}
+	}
+	for alias, groupID := range s.aliases {
+		if groupID == id {
+			delete(s.aliases, alias)
+		}
+	}
+
+	return nil
+}
+
+func (s *memoryStorage) AliasGroup(ctx context.Context, id, targetID int) error {
+	s.infoLog.Print("Заменяем группу псевдонимом в памяти")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.groups[id]; !ok {
+		return ErrNotFound
+	}
+	if _, ok := s.groups[targetID]; !ok {
+		return ErrNotFound
+	}
+	for _, song := range s.songs {
+		if song.groupID != nil && *song.groupID == id {
+			return ErrGroupNotEmpty
+		}
+	}
+
+	for _, album := range s.albums {
+		if album.groupID != nil && *album.groupID == id {
+			album.groupID = copyInt(&targetID)
+		}
+	}
+	for alias, groupID := range s.aliases {
+		if groupID == id {
+			s.aliases[alias] = targetID
+		}
+	}
+	s.aliases[id] = targetID
+	delete(s.groups, id)
+
+	return nil
+}
+
+func (s *memoryStorage) ResolveGroupAlias(ctx context.Context, id int) (int, error) {
+	s.infoLog.Print("Ищем псевдоним группы в памяти")
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	groupID, ok := s.aliases[id]
+	if !ok {
+		return 0, ErrNotFound
+	}
+	return groupID, nil
+}
@@ -0,0 +1,859 @@
+package storage
+
+import (
+	"context"
+	"effectiveMobile/internal/searchkey"
+	"effectiveMobile/models"
+	"errors"
+	"log"
+	"maps"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	errSongNameRequired  = errors.New("название песни обязательно")
+	errGroupNameRequired = errors.New("название группы обязательно")
+)
+
+type memoryGroup struct {
+	id         int
+	name       string
+	key        string
+	country    *string
+	formedYear *int
+	genres     []string
+	bio        *string
+}
+
+type memorySong struct {
+	id          int
+	groupID     *int
+	name        string
+	key         string
+	releaseDate *models.Date
+	precision   models.DatePrecision
+	text        *string
+	link        *string
+	albumID     *int
+	discNumber  *int
+	trackNumber *int
+	version     int
+
+	needsEnrichment   bool
+	enrichAttempts    int
+	enrichLastError   *string
+	enrichLastAttempt *time.Time
+}
+
+// memoryStorage хранит песни в памяти процесса. Подходит для тестов и
+// демонстрационного режима: повторяет поведение хранилища Postgres,
+// включая ошибки ErrNotFound и ErrConflict, но теряет данные при остановке.
+type memoryStorage struct {
+	mu sync.RWMutex
+
+	groups map[int]*memoryGroup
+	songs  map[int]*memorySong
+	albums map[int]*memoryAlbum
+	// aliases — ID влитых групп и группы, в которые их влили
+	aliases     map[int]int
+	nextGroupID int
+	nextSongID  int
+	nextAlbumID int
+
+	infoLog  *log.Logger
+	errorLog *log.Logger
+}
+
+func NewMemorySongStorage(infoLog, errorLog *log.Logger) SongStorage {
+	return &memoryStorage{
+		groups:      make(map[int]*memoryGroup),
+		songs:       make(map[int]*memorySong),
+		albums:      make(map[int]*memoryAlbum),
+		aliases:     make(map[int]int),
+		nextGroupID: 1,
+		nextSongID:  1,
+		nextAlbumID: 1,
+		infoLog:     infoLog,
+		errorLog:    errorLog,
+	}
+}
+
+// toModel собирает песню в том же виде, в каком её возвращают запросы Postgres
+func (s *memoryStorage) toModel(song *memorySong, withText bool) models.Song {
+	id := song.id
+	name := song.name
+	result := models.Song{
+		ID:                   &id,
+		Name:                 &name,
+		ReleaseDate:          copyDate(song.releaseDate),
+		ReleaseDatePrecision: song.precision,
+		Link:                 copyString(song.link),
+		AlbumID:              copyInt(song.albumID),
+		DiscNumber:           copyInt(song.discNumber),
+		TrackNumber:          copyInt(song.trackNumber),
+	}
+
+	if group, ok := s.group(song.groupID); ok {
+		groupName := group.name
+		result.Group_name = &groupName
+	}
+	if withText {
+		result.Text = copyString(song.text)
+		result.Version = song.version
+	}
+
+	return result
+}
+
+// group возвращает группу песни. Песни без группы, как и при INNER JOIN,
+// в выборки не попадают.
+func (s *memoryStorage) group(id *int) (*memoryGroup, bool) {
+	if id == nil {
+		return nil, false
+	}
+	group, ok := s.groups[*id]
+	return group, ok
+}
+
+func (s *memoryStorage) GetAllSongs(ctx context.Context, filter models.SongFilter, page models.Pagination) (models.SongPage, error) {
+	s.infoLog.Print("Получаем все песни из памяти")
+	result := models.SongPage{Items: []models.Song{}}
+
+	order, err := resolveSongOrder(page.Sort)
+	if err != nil {
+		return result, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	type sortedSong struct {
+		song models.Song
+		keys []string
+	}
+
+	var matched []sortedSong
+	for _, song := range s.songs {
+		group, ok := s.group(song.groupID)
+		if !ok || !matchesSongFilter(song, group, s.album(song.albumID), filter) {
+			continue
+		}
+		model := s.toModel(song, false)
+		matched = append(matched, sortedSong{song: model, keys: order.keys(model)})
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		return order.compare(matched[i].keys, matched[j].keys) < 0
+	})
+	result.Total = len(matched)
+
+	start := 0
+	if page.After != "" {
+		cursor, err := decodeCursor(page.After)
+		if err != nil {
+			return result, err
+		}
+		if err := order.checkCursor(cursor); err != nil {
+			return result, err
+		}
+		start = sort.Search(len(matched), func(i int) bool {
+			return order.compare(matched[i].keys, cursor.Values) > 0
+		})
+	} else {
+		start = min((page.Page-1)*page.Limit, len(matched))
+	}
+
+	end := min(start+page.Limit, len(matched))
+	for _, item := range matched[start:end] {
+		result.Items = append(result.Items, item.song)
+	}
+
+	if end < len(matched) && len(result.Items) > 0 {
+		result.NextCursor = order.cursor(result.Items[len(result.Items)-1])
+	}
+
+	return result, nil
+}
+
+func matchesSongFilter(song *memorySong, group *memoryGroup, album *memoryAlbum, filter models.SongFilter) bool {
+	if filter.Name != "" && !matchKey(song.key, filter.Match, searchkey.Key(filter.Name)) {
+		return false
+	}
+	if filter.Group != "" && !matchKey(group.key, filter.Match, searchkey.Key(filter.Group)) {
+		return false
+	}
+	if filter.GroupID != nil && *filter.GroupID != group.id {
+		return false
+	}
+	if filter.Album != "" && (album == nil || !matchKey(album.key, filter.Match, searchkey.Key(filter.Album))) {
+		return false
+	}
+	if filter.AlbumID != nil && (song.albumID == nil || *song.albumID != *filter.AlbumID) {
+		return false
+	}
+
+	if filter.ReleasedFrom != nil || filter.ReleasedTo != nil {
+		if song.releaseDate == nil {
+			return false
+		}
+		date := song.releaseDate.Format("2006-01-02")
+		if filter.ReleasedFrom != nil && date < filter.ReleasedFrom.Format("2006-01-02") {
+			return false
+		}
+		if filter.ReleasedTo != nil && date > filter.ReleasedTo.Format("2006-01-02") {
+			return false
+		}
+	}
+
+	if filter.Text != "" {
+		if song.text == nil || !strings.Contains(strings.ToLower(*song.text), strings.ToLower(filter.Text)) {
+			return false
+		}
+	}
+
+	if filter.HasLink != nil {
+		hasLink := song.link != nil && *song.link != ""
+		if hasLink != *filter.HasLink {
+			return false
+		}
+	}
+
+	return true
+}
+
+func matchKey(key string, mode models.MatchMode, value string) bool {
+	switch mode {
+	case models.MatchExact:
+		return key == value
+	case models.MatchPrefix:
+		return strings.HasPrefix(key, value)
+	default:
+		return strings.Contains(key, value)
+	}
+}
+
+func (s *memoryStorage) GetSongByID(ctx context.Context, id int) (models.Song, error) {
+	s.infoLog.Print("Получаем песню по ID из памяти")
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	song, ok := s.songs[id]
+	if !ok {
+		return models.Song{}, ErrNotFound
+	}
+	if _, ok := s.group(song.groupID); !ok {
+		return models.Song{}, ErrNotFound
+	}
+
+	return s.toModel(song, true), nil
+}
+
+// Веса полей при ранжировании, как у setweight A, B и C в Postgres
+const (
+	memoryRankName  = 1.0
+	memoryRankGroup = 0.4
+	memoryRankText  = 0.2
+)
+
+// SearchSongs — упрощённый полнотекстовый поиск: песня подходит, если каждое
+// слово запроса является началом какого-либо слова в названии, группе или тексте
+func (s *memoryStorage) SearchSongs(ctx context.Context, search models.SearchQuery, page models.Pagination) (models.SongSearchPage, error) {
+	s.infoLog.Print("Ищем песни в памяти")
+	result := models.SongSearchPage{Items: []models.SongSearchHit{}}
+
+	if _, ok := searchConfigs[search.Lang]; !ok {
+		return result, ErrInvalidSearch
+	}
+
+	terms := words(search.Text)
+	if len(terms) == 0 {
+		return result, nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var hits []models.SongSearchHit
+	for _, song := range s.songs {
+		group, ok := s.group(song.groupID)
+		if !ok {
+			continue
+		}
+
+		text := ""
+		if song.text != nil {
+			text = *song.text
+		}
+
+		rank, ok := rankTerms(terms, song.name, group.name, text)
+		if !ok {
+			continue
+		}
+
+		hits = append(hits, models.SongSearchHit{
+			Song:    s.toModel(song, false),
+			Rank:    float32(rank),
+			Snippet: headline(strings.TrimSpace(song.name+"\n"+text), terms),
+		})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Rank != hits[j].Rank {
+			return hits[i].Rank > hits[j].Rank
+		}
+		return *hits[i].ID < *hits[j].ID
+	})
+
+	result.Total = len(hits)
+	start := min((page.Page-1)*page.Limit, len(hits))
+	end := min(start+page.Limit, len(hits))
+	result.Items = append(result.Items, hits[start:end]...)
+
+	return result, nil
+}
+
+// rankTerms проверяет, что все слова запроса найдены, и считает релевантность
+func rankTerms(terms []string, name, group, text string) (float64, bool) {
+	fields := []struct {
+		words  []string
+		weight float64
+	}{
+		{words(name), memoryRankName},
+		{words(group), memoryRankGroup},
+		{words(text), memoryRankText},
+	}
+
+	rank := 0.0
+	for _, term := range terms {
+		found := false
+		for _, field := range fields {
+			for _, word := range field.words {
+				if strings.HasPrefix(word, term) {
+					rank += field.weight
+					found = true
+				}
+			}
+		}
+		if !found {
+			return 0, false
+		}
+	}
+
+	// Как ts_rank с нормализацией по умолчанию, оценка меньше единицы
+	return 1 - math.Exp(-rank), true
+}
+
+// headline выделяет совпавшие слова тегами <b></b> и оставляет
+// не больше 20 слов вокруг первого совпадения
+func headline(text string, terms []string) string {
+	const maxWords = 20
+
+	tokens := strings.Fields(text)
+	first := -1
+	for i, token := range tokens {
+		lower := strings.ToLower(token)
+		for _, term := range terms {
+			if strings.HasPrefix(strings.TrimLeftFunc(lower, isNotWordRune), term) {
+				tokens[i] = "<b>" + token + "</b>"
+				if first < 0 {
+					first = i
+				}
+				break
+			}
+		}
+	}
+
+	start := max(first-5, 0)
+	end := min(start+maxWords, len(tokens))
+
+	return strings.Join(tokens[start:end], " ")
+}
+
+func (s *memoryStorage) FuzzySearchSongs(ctx context.Context, query string, threshold float64, limit int) (models.FuzzySongPage, error) {
+	s.infoLog.Print("Ищем песни с опечатками в памяти")
+	result := models.FuzzySongPage{Items: []models.FuzzySongHit{}}
+	key := searchkey.Key(query)
+	lowerQuery := strings.ToLower(query)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var hits []models.FuzzySongHit
+	for _, song := range s.songs {
+		group, ok := s.group(song.groupID)
+		if !ok {
+			continue
+		}
+
+		if strings.Contains(strings.ToLower(song.name), lowerQuery) ||
+			strings.Contains(strings.ToLower(group.name), lowerQuery) ||
+			strings.Contains(song.key, key) || strings.Contains(group.key, key) {
+			result.ExactHits++
+		}
+
+		score := max(
+			similarity(song.name, query),
+			similarity(group.name, query),
+			similarity(group.name+" "+song.name, query),
+			similarity(song.key, key),
+			similarity(group.key, key),
+		)
+		if score < threshold {
+			continue
+		}
+
+		hits = append(hits, models.FuzzySongHit{Song: s.toModel(song, false), Score: float32(score)})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return *hits[i].ID < *hits[j].ID
+	})
+	result.Items = append(result.Items, hits[:min(limit, len(hits))]...)
+
+	if result.ExactHits == 0 {
+		result.Suggestion = s.suggestName(query, threshold)
+	}
+
+	return result, nil
+}
+
+// suggestName ищет название песни или группы, наиболее похожее на запрос
+func (s *memoryStorage) suggestName(query string, threshold float64) string {
+	best, bestScore := "", 0.0
+
+	consider := func(name string) {
+		score := similarity(name, query)
+		if score < threshold {
+			return
+		}
+		if score > bestScore || (score == bestScore && name < best) {
+			best, bestScore = name, score
+		}
+	}
+
+	for _, song := range s.songs {
+		consider(song.name)
+	}
+	for _, group := range s.groups {
+		consider(group.name)
+	}
+
+	return best
+}
+
+func (s *memoryStorage) AddSong(ctx context.Context, song models.Song) (int, error) {
+	s.infoLog.Print("Добавляем песню в память")
+
+	if song.Name == nil {
+		return 0, errSongNameRequired
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if song.Group != nil {
+		if _, ok := s.groups[*song.Group]; !ok {
+			return 0, ErrNotFound
+		}
+	}
+	if existing, taken := s.songTaken(song.Group, *song.Name, 0); taken {
+		return 0, &ConflictError{ID: existing}
+	}
+
+	id := s.nextSongID
+	s.songs[id] = &memorySong{
+		id:              id,
+		groupID:         copyInt(song.Group),
+		name:            *song.Name,
+		key:             searchkey.Key(*song.Name),
+		releaseDate:     copyDate(song.ReleaseDate),
+		precision:       releasePrecision(song.ReleaseDate, song.ReleaseDatePrecision),
+		text:            copyString(song.Text),
+		link:            copyString(song.Link),
+		version:         1,
+		needsEnrichment: song.NeedsEnrichment,
+	}
+	s.nextSongID++
+
+	return id, nil
+}
+
+func (s *memoryStorage) FindSongByName(ctx context.Context, groupID int, name string) (int, error) {
+	s.infoLog.Print("Ищем песню группы по названию в памяти")
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if id, taken := s.songTaken(&groupID, name, 0); taken {
+		return id, nil
+	}
+	return 0, ErrNotFound
+}
+
+// songTaken ищет другую песню группы с тем же ключом названия, как
+// уникальный индекс (group_id, name_key). Песни без группы не конфликтуют.
+func (s *memoryStorage) songTaken(groupID *int, name string, exceptID int) (int, bool) {
+	if groupID == nil {
+		return 0, false
+	}
+
+	key := searchkey.NameKey(name)
+	for _, song := range s.songs {
+		if song.groupID != nil && *song.groupID == *groupID && song.id != exceptID &&
+			searchkey.NameKey(song.name) == key {
+			return song.id, true
+		}
+	}
+	return 0, false
+}
+
+// songVersion ищет песню для изменения и проверяет её версию
+func (s *memoryStorage) songVersion(id int, version int) (*memorySong, error) {
+	song, ok := s.songs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if version != AnyVersion && song.version != version {
+		return nil, ErrVersionMismatch
+	}
+	return song, nil
+}
+
+func (s *memoryStorage) UpdateSong(ctx context.Context, id int, newSong models.Song, version int) error {
+	s.infoLog.Print("Обновляем песню в памяти")
+
+	if newSong.Name == nil {
+		return errSongNameRequired
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	song, err := s.songVersion(id, version)
+	if err != nil {
+		return err
+	}
+	if _, taken := s.songTaken(song.groupID, *newSong.Name, id); taken {
+		return ErrConflict
+	}
+
+	song.name = *newSong.Name
+	song.key = searchkey.Key(*newSong.Name)
+	song.text = copyString(newSong.Text)
+	song.link = copyString(newSong.Link)
+	song.version++
+
+	return nil
+}
+
+func (s *memoryStorage) PatchSong(ctx context.Context, id int, changes models.SongChanges, version int) error {
+	s.infoLog.Print("Частично обновляем песню в памяти")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	song, err := s.songVersion(id, version)
+	if err != nil {
+		return err
+	}
+
+	// Сначала проверяем все ограничения, чтобы не изменить песню частично
+	name, groupID := song.name, song.groupID
+	if changes.Name.Set {
+		if changes.Name.Value == nil {
+			return errSongNameRequired
+		}
+		name = *changes.Name.Value
+	}
+	if changes.GroupID.Set {
+		groupID = changes.GroupID.Value
+		if groupID != nil {
+			if _, ok := s.groups[*groupID]; !ok {
+				return ErrNotFound
+			}
+		}
+	}
+	albumID, disc, track := song.albumID, song.discNumber, song.trackNumber
+	if changes.AlbumID.Set {
+		albumID = changes.AlbumID.Value
+		if albumID != nil {
+			if _, ok := s.albums[*albumID]; !ok {
+				return ErrNotFound
+			}
+		}
+	}
+	if changes.DiscNumber.Set {
+		disc = changes.DiscNumber.Value
+	}
+	if changes.TrackNumber.Set {
+		track = changes.TrackNumber.Value
+	}
+	if _, taken := s.songTaken(groupID, name, id); taken {
+		return ErrConflict
+	}
+	if s.trackTaken(albumID, disc, track, id) {
+		return ErrConflict
+	}
+
+	if changes.Name.Set {
+		song.name = *changes.Name.Value
+		song.key = searchkey.Key(song.name)
+	}
+	if changes.GroupID.Set {
+		song.groupID = copyInt(changes.GroupID.Value)
+	}
+	if changes.ReleaseDate.Set {
+		song.releaseDate = copyDate(changes.ReleaseDate.Value)
+		song.precision = releasePrecision(changes.ReleaseDate.Value, changes.ReleaseDatePrecision)
+	}
+	if changes.Text.Set {
+		song.text = copyString(changes.Text.Value)
+	}
+	if changes.Link.Set {
+		song.link = copyString(changes.Link.Value)
+	}
+	if changes.AlbumID.Set {
+		song.albumID = copyInt(changes.AlbumID.Value)
+	}
+	if changes.DiscNumber.Set {
+		song.discNumber = copyInt(changes.DiscNumber.Value)
+	}
+	if changes.TrackNumber.Set {
+		song.trackNumber = copyInt(changes.TrackNumber.Value)
+	}
+	song.version++
+
+	return nil
+}
+
+func (s *memoryStorage) DeleteSong(ctx context.Context, id int, version int) error {
+	s.infoLog.Print("Удаляем песню из памяти")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.songVersion(id, version); err != nil {
+		return err
+	}
+	delete(s.songs, id)
+
+	return nil
+}
+
+func (s *memoryStorage) AddGroup(ctx context.Context, group models.Group) (int, error) {
+	s.infoLog.Print("Добавляем группу в память")
+
+	if group.Name == nil {
+		return 0, errGroupNameRequired
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Как groupByNameKeyQuery: точное совпадение названия важнее,
+	// затем группа с меньшим ID и тем же названием без учёта регистра и пробелов
+	key, match := searchkey.NameKey(*group.Name), 0
+	for _, existing := range s.groups {
+		if existing.name == *group.Name {
+			// Артист уже существует, возвращаем его ID
+			return existing.id, nil
+		}
+		if searchkey.NameKey(existing.name) == key && (match == 0 || existing.id < match) {
+			match = existing.id
+		}
+	}
+	if match != 0 {
+		return match, nil
+	}
+
+	id := s.nextGroupID
+	s.groups[id] = &memoryGroup{id: id, name: *group.Name, key: searchkey.Key(*group.Name)}
+	s.nextGroupID++
+
+	return id, nil
+}
+
+// WithTx выполняет fn над копией данных и при успехе подменяет ими
+// исходные. На время fn хранилище заблокировано, поэтому транзакции
+// выполняются по очереди, как при блокировке записи в базе.
+func (s *memoryStorage) WithTx(ctx context.Context, fn func(tx SongStorage) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx := &memoryStorage{
+		groups:      make(map[int]*memoryGroup, len(s.groups)),
+		songs:       make(map[int]*memorySong, len(s.songs)),
+		albums:      make(map[int]*memoryAlbum, len(s.albums)),
+		aliases:     maps.Clone(s.aliases),
+		nextGroupID: s.nextGroupID,
+		nextSongID:  s.nextSongID,
+		nextAlbumID: s.nextAlbumID,
+		infoLog:     s.infoLog,
+		errorLog:    s.errorLog,
+	}
+	// Поля-указатели при изменении заменяются, а не правятся на месте,
+	// поэтому достаточно скопировать сами записи
+	for id, group := range s.groups {
+		copied := *group
+		tx.groups[id] = &copied
+	}
+	for id, song := range s.songs {
+		copied := *song
+		tx.songs[id] = &copied
+	}
+	for id, album := range s.albums {
+		copied := *album
+		tx.albums[id] = &copied
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	s.groups, s.songs, s.albums, s.aliases = tx.groups, tx.songs, tx.albums, tx.aliases
+	s.nextGroupID, s.nextSongID, s.nextAlbumID = tx.nextGroupID, tx.nextSongID, tx.nextAlbumID
+	return nil
+}
+
+func (s *memoryStorage) GetIncompleteSongs(ctx context.Context, limit, maxAttempts int, retryDelay time.Duration) ([]models.Song, error) {
+	s.infoLog.Print("Получаем неполные песни из памяти")
+	now := time.Now()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var incomplete []*memorySong
+	for _, song := range s.songs {
+		if _, ok := s.group(song.groupID); !ok {
+			continue
+		}
+		if song.text != nil && song.link != nil && !song.needsEnrichment {
+			continue
+		}
+		if song.enrichAttempts >= maxAttempts {
+			continue
+		}
+		if song.enrichLastAttempt != nil {
+			wait := time.Duration(float64(retryDelay) * math.Pow(2, float64(song.enrichAttempts)))
+			if !song.enrichLastAttempt.Before(now.Add(-wait)) {
+				continue
+			}
+		}
+		incomplete = append(incomplete, song)
+	}
+
+	// Сначала песни, которые ещё не пробовали обогащать
+	sort.Slice(incomplete, func(i, j int) bool {
+		a, b := incomplete[i].enrichLastAttempt, incomplete[j].enrichLastAttempt
+		switch {
+		case a == nil && b != nil:
+			return true
+		case a != nil && b == nil:
+			return false
+		case a != nil && b != nil && !a.Equal(*b):
+			return a.Before(*b)
+		}
+		return incomplete[i].id < incomplete[j].id
+	})
+
+	songs := make([]models.Song, 0, min(limit, len(incomplete)))
+	for _, song := range incomplete[:min(limit, len(incomplete))] {
+		model := s.toModel(song, false)
+		songs = append(songs, models.Song{ID: model.ID, Name: model.Name, Group_name: model.Group_name})
+	}
+
+	return songs, nil
+}
+
+func (s *memoryStorage) SaveEnrichment(ctx context.Context, id int, enriched models.Song) error {
+	s.infoLog.Print("Сохраняем обогащение песни в памяти")
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	song, ok := s.songs[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if enriched.ReleaseDate != nil {
+		song.releaseDate = copyDate(enriched.ReleaseDate)
+		song.precision = releasePrecision(enriched.ReleaseDate, enriched.ReleaseDatePrecision)
+	}
+	if song.text == nil {
+		song.text = copyString(enriched.Text)
+	}
+	if song.link == nil {
+		song.link = copyString(enriched.Link)
+	}
+	song.needsEnrichment = false
+	if song.text != nil && song.link != nil {
+		song.enrichAttempts = 0
+		song.enrichLastError = nil
+	} else {
+		reason := enrichPartialReason
+		song.enrichAttempts++
+		song.enrichLastError = &reason
+	}
+	song.enrichLastAttempt = &now
+	song.version++
+
+	return nil
+}
+
+func (s *memoryStorage) MarkEnrichmentFailed(ctx context.Context, id int, reason string) error {
+	s.infoLog.Print("Отмечаем неудачное обогащение песни в памяти")
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	song, ok := s.songs[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	song.enrichAttempts++
+	song.enrichLastError = &reason
+	song.enrichLastAttempt = &now
+
+	return nil
+}
+
+// RefreshSearchKeys ничего не делает: ключи вычисляются при каждой записи
+func (s *memoryStorage) RefreshSearchKeys(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+func copyString(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	copied := *value
+	return &copied
+}
+
+func copyInt(value *int) *int {
+	if value == nil {
+		return nil
+	}
+	copied := *value
+	return &copied
+}
+
+func copyDate(value *models.Date) *models.Date {
+	if value == nil {
+		return nil
+	}
+	copied := *value
+	return &copied
+}
@@ -0,0 +1,17 @@
+package storage_test
+
+import (
+	"effectiveMobile/internal/storage"
+	"effectiveMobile/internal/storage/storagetest"
+	"io"
+	"log"
+	"testing"
+)
+
+func TestMemorySongStorage(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+
+	storagetest.RunSongStorageContract(t, func(t *testing.T) storage.SongStorage {
+		return storage.NewMemorySongStorage(logger, logger)
+	})
+}
@@ -5,23 +5,103 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 )
 
-func GetPostgres() (*pgx.Conn, error) {
+// DB — то, что хранилищу нужно от соединения с базой. Ему удовлетворяют
+// и пул соединений, и транзакция.
+type DB interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// PoolStats — снимок состояния пула соединений
+type PoolStats struct {
+	MaxConns             int32         `json:"maxConns"`
+	TotalConns           int32         `json:"totalConns"`
+	AcquiredConns        int32         `json:"acquiredConns"`
+	IdleConns            int32         `json:"idleConns"`
+	ConstructingConns    int32         `json:"constructingConns"`
+	AcquireCount         int64         `json:"acquireCount"`
+	EmptyAcquireCount    int64         `json:"emptyAcquireCount"`
+	CanceledAcquireCount int64         `json:"canceledAcquireCount"`
+	AcquireDuration      time.Duration `json:"acquireDurationNs"`
+	NewConnsCount        int64         `json:"newConnsCount"`
+	MaxLifetimeDestroyed int64         `json:"maxLifetimeDestroyCount"`
+	MaxIdleDestroyed     int64         `json:"maxIdleDestroyCount"`
+}
+
+func GetPostgres() (*pgxpool.Pool, error) {
 	dsn, err := buildDSN()
 	if err != nil {
 		log.Fatalf("Ошибка загрузки .env файла: %v", err)
 	}
 
-	conn, err := pgx.Connect(context.Background(), dsn)
+	config, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+	configurePool(config)
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), config)
 	if err != nil {
 		log.Fatalf("Невозможно подключиться к базе данных: %v\n", err)
 	}
 
-	return conn, err
+	if err = pool.Ping(context.Background()); err != nil {
+		log.Fatalf("Невозможно подключиться к базе данных: %v\n", err)
+	}
+
+	return pool, err
+}
+
+// GetPoolStats возвращает статистику пула соединений
+func GetPoolStats(pool *pgxpool.Pool) PoolStats {
+	stat := pool.Stat()
+
+	return PoolStats{
+		MaxConns:             stat.MaxConns(),
+		TotalConns:           stat.TotalConns(),
+		AcquiredConns:        stat.AcquiredConns(),
+		IdleConns:            stat.IdleConns(),
+		ConstructingConns:    stat.ConstructingConns(),
+		AcquireCount:         stat.AcquireCount(),
+		EmptyAcquireCount:    stat.EmptyAcquireCount(),
+		CanceledAcquireCount: stat.CanceledAcquireCount(),
+		AcquireDuration:      stat.AcquireDuration(),
+		NewConnsCount:        stat.NewConnsCount(),
+		MaxLifetimeDestroyed: stat.MaxLifetimeDestroyCount(),
+		MaxIdleDestroyed:     stat.MaxIdleDestroyCount(),
+	}
+}
+
+// configurePool задаёт размер пула, время жизни соединений и период
+// проверки их состояния из переменных окружения. Незаданные параметры
+// остаются значениями pgxpool по умолчанию.
+func configurePool(config *pgxpool.Config) {
+	if value, err := strconv.Atoi(os.Getenv("POSTGRES_POOL_MAX_CONNS")); err == nil {
+		config.MaxConns = int32(value)
+	}
+	if value, err := strconv.Atoi(os.Getenv("POSTGRES_POOL_MIN_CONNS")); err == nil {
+		config.MinConns = int32(value)
+	}
+	if value, err := time.ParseDuration(os.Getenv("POSTGRES_POOL_MAX_CONN_LIFETIME")); err == nil {
+		config.MaxConnLifetime = value
+	}
+	if value, err := time.ParseDuration(os.Getenv("POSTGRES_POOL_MAX_CONN_IDLE_TIME")); err == nil {
+		config.MaxConnIdleTime = value
+	}
+	if value, err := time.ParseDuration(os.Getenv("POSTGRES_POOL_HEALTH_CHECK_PERIOD")); err == nil {
+		config.HealthCheckPeriod = value
+	}
 }
 
 func buildDSN() (string, error) {
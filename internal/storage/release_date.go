@@ -0,0 +1,23 @@
+package storage
+
+import "effectiveMobile/models"
+
+// dateString переводит дату в формат YYYY-MM-DD, в котором её принимают
+// и столбец DATE в Postgres, и текстовое представление дат в SQLite
+func dateString(date *models.Date) *string {
+	if date == nil {
+		return nil
+	}
+
+	value := date.String()
+	return &value
+}
+
+// releasePrecision возвращает точность даты релиза для записи в базу.
+// Если точность не указана или даты нет, дата считается известной до дня.
+func releasePrecision(date *models.Date, precision models.DatePrecision) models.DatePrecision {
+	if date == nil || precision == "" {
+		return models.PrecisionDay
+	}
+	return precision
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"effectiveMobile/internal/searchkey"
+	"errors"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+)
+
+// searchKey строит поисковый ключ для названия, которое может быть не задано
+func searchKey(name *string) *string {
+	if name == nil {
+		return nil
+	}
+
+	key := searchkey.Key(*name)
+	return &key
+}
+
+// nameKey строит ключ уникальности названия песни в пределах группы
+// и ключ, по которому находится группа
+func nameKey(name *string) *string {
+	if name == nil {
+		return nil
+	}
+
+	key := searchkey.NameKey(*name)
+	return &key
+}
+
+// existingSongQuery ищет песню группы по ключу уникальности названия
+const existingSongQuery = "SELECT id FROM songs WHERE group_id = $1 AND name_key = $2"
+
+// groupByNameKeyQuery ищет группу по ключу названия $1. Если групп-дубликатов
+// несколько, точное совпадение названия $2 важнее, затем меньший ID.
+const groupByNameKeyQuery = "SELECT id FROM groups WHERE name_key = $1 ORDER BY group_name = $2 DESC, id LIMIT 1"
+
+type namedRow struct {
+	ID   int
+	Name string
+}
+
+// keyColumns — ключи, которые вычисляет приложение, а миграции и начальные
+// данные оставляют пустыми. Запросы одинаковы для Postgres и SQLite.
+var keyColumns = []struct {
+	selectQuery string
+	updateQuery string
+	key         func(string) string
+}{
+	{
+		selectQuery: "SELECT id, group_name name FROM groups WHERE search_key IS NULL ORDER BY id",
+		updateQuery: "UPDATE groups SET search_key = $1 WHERE id = $2",
+		key:         searchkey.Key,
+	},
+	{
+		selectQuery: "SELECT id, song_name name FROM songs WHERE search_key IS NULL ORDER BY id",
+		updateQuery: "UPDATE songs SET search_key = $1 WHERE id = $2",
+		key:         searchkey.Key,
+	},
+	{
+		selectQuery: "SELECT id, title name FROM albums WHERE search_key IS NULL ORDER BY id",
+		updateQuery: "UPDATE albums SET search_key = $1 WHERE id = $2",
+		key:         searchkey.Key,
+	},
+	{
+		selectQuery: "SELECT id, group_name name FROM groups WHERE name_key IS NULL ORDER BY id",
+		updateQuery: "UPDATE groups SET name_key = $1 WHERE id = $2",
+		key:         searchkey.NameKey,
+	},
+	{
+		selectQuery: "SELECT id, song_name name FROM songs WHERE name_key IS NULL ORDER BY id",
+		updateQuery: "UPDATE songs SET name_key = $1 WHERE id = $2",
+		key:         searchkey.NameKey,
+	},
+}
+
+// RefreshSearchKeys заполняет поисковые ключи и ключи уникальности групп,
+// альбомов и песен, добавленных в обход приложения, например миграцией
+// начальных данных. Песня, название которой совпало с более ранней песней
+// той же группы, остаётся без ключа уникальности: такие песни возвращаются
+// в *DuplicateNamesError после заполнения остальных ключей.
+// Возвращает число обновлённых строк.
+func (s *songStorage) RefreshSearchKeys(ctx context.Context) (int, error) {
+	s.infoLog.Print("Запускаем SQL запрос по заполнению поисковых ключей")
+	updated := 0
+	var duplicates []int
+
+	for _, column := range keyColumns {
+		var rows []namedRow
+		if err := pgxscan.Select(ctx, s.db, &rows, column.selectQuery); err != nil {
+			s.errorLog.Println(err)
+			return updated, err
+		}
+
+		for _, row := range rows {
+			_, err := s.db.Exec(ctx, column.updateQuery, column.key(row.Name), row.ID)
+			if errors.Is(mapPgError(err), ErrConflict) {
+				duplicates = append(duplicates, row.ID)
+				continue
+			}
+			if err != nil {
+				s.errorLog.Println(err)
+				return updated, err
+			}
+			updated++
+		}
+	}
+
+	if len(duplicates) > 0 {
+		return updated, &DuplicateNamesError{IDs: duplicates}
+	}
+	return updated, nil
+}
@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"effectiveMobile/internal/searchkey"
+	"effectiveMobile/models"
+	"fmt"
+	"strings"
+)
+
+// whereBuilder собирает условие WHERE. Значения фильтров передаются
+// только через параметры запроса, в текст SQL они не попадают.
+type whereBuilder struct {
+	conds []string
+	args  []any
+	ilike likeFunc
+}
+
+// likeFunc строит регистронезависимое сравнение выражения с шаблоном LIKE
+type likeFunc func(expr, pattern string) string
+
+func postgresILike(expr, pattern string) string {
+	return fmt.Sprintf(`%s ILIKE %s ESCAPE '\'`, expr, pattern)
+}
+
+// arg добавляет параметр и возвращает его плейсхолдер
+func (b *whereBuilder) arg(value any) string {
+	b.args = append(b.args, value)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+func (b *whereBuilder) add(cond string) {
+	b.conds = append(b.conds, cond)
+}
+
+func (b *whereBuilder) sql() string {
+	if len(b.conds) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(b.conds, " AND ")
+}
+
+// buildSongWhere переводит фильтр песен в условие над songs s и groups g
+func buildSongWhere(filter models.SongFilter, ilike likeFunc) *whereBuilder {
+	b := &whereBuilder{ilike: ilike}
+
+	// Названия сравниваются по поисковым ключам, поэтому «Кино» находится и по "kino"
+	if filter.Name != "" {
+		b.add(matchCondition("s.search_key", filter.Match, searchkey.Key(filter.Name), b))
+	}
+	if filter.Group != "" {
+		b.add(matchCondition("g.search_key", filter.Match, searchkey.Key(filter.Group), b))
+	}
+	if filter.GroupID != nil {
+		b.add("s.group_id = " + b.arg(*filter.GroupID))
+	}
+	if filter.Album != "" {
+		b.add("EXISTS (SELECT 1 FROM albums a WHERE a.id = s.album_id AND " +
+			matchCondition("a.search_key", filter.Match, searchkey.Key(filter.Album), b) + ")")
+	}
+	if filter.AlbumID != nil {
+		b.add("s.album_id = " + b.arg(*filter.AlbumID))
+	}
+	// Даты хранятся в формате YYYY-MM-DD, поэтому их можно сравнивать как строки
+	if filter.ReleasedFrom != nil {
+		b.add("s.release_date >= " + b.arg(filter.ReleasedFrom.Format("2006-01-02")))
+	}
+	if filter.ReleasedTo != nil {
+		b.add("s.release_date <= " + b.arg(filter.ReleasedTo.Format("2006-01-02")))
+	}
+	if filter.Text != "" {
+		b.add(matchCondition("s.text", models.MatchContains, filter.Text, b))
+	}
+	if filter.HasLink != nil {
+		if *filter.HasLink {
+			b.add("COALESCE(s.link, '') <> ''")
+		} else {
+			b.add("COALESCE(s.link, '') = ''")
+		}
+	}
+
+	return b
+}
+
+func matchCondition(column string, mode models.MatchMode, value string, b *whereBuilder) string {
+	switch mode {
+	case models.MatchExact:
+		return fmt.Sprintf("%s = %s", column, b.arg(value))
+	case models.MatchPrefix:
+		return b.ilike(column, b.arg(escapeLike(value)+"%"))
+	default:
+		return b.ilike(column, b.arg("%"+escapeLike(value)+"%"))
+	}
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
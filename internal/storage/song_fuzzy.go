@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"effectiveMobile/internal/searchkey"
+	"effectiveMobile/models"
+	"fmt"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+)
+
+// FuzzySearchSongs ищет песни, у которых название, группа или их сочетание
+// похожи на запрос не меньше чем на threshold (similarity из pg_trgm).
+// Порог ставится для транзакции, чтобы операторы % использовали GIN индексы.
+func (s *songStorage) FuzzySearchSongs(ctx context.Context, query string, threshold float64, limit int) (models.FuzzySongPage, error) {
+	s.infoLog.Print("Запускаем SQL запрос по нечёткому поиску песен")
+	result := models.FuzzySongPage{Items: []models.FuzzySongHit{}}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		s.errorLog.Println(err)
+		return result, err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, "SELECT set_config('pg_trgm.similarity_threshold', $1, true)", fmt.Sprint(threshold))
+	if err != nil {
+		s.errorLog.Println(err)
+		return result, err
+	}
+
+	// Сравниваем и исходные названия, и поисковые ключи, чтобы
+	// запрос латиницей находил кириллические названия и наоборот
+	searchQuery := `SELECT s.id, song_name name, group_name, release_date, release_date_precision, link,
+		GREATEST(
+			similarity(s.song_name, $1),
+			similarity(g.group_name, $1),
+			similarity(g.group_name || ' ' || s.song_name, $1),
+			similarity(s.search_key, $2),
+			similarity(g.search_key, $2)
+		) score
+	FROM songs s
+	INNER JOIN groups g ON g.id = s.group_id
+	WHERE s.song_name % $1 OR g.group_name % $1 OR (g.group_name || ' ' || s.song_name) % $1
+		OR s.search_key % $2 OR g.search_key % $2
+	ORDER BY score DESC, s.id
+	LIMIT $3`
+
+	key := searchkey.Key(query)
+	err = pgxscan.Select(ctx, tx, &result.Items, searchQuery, query, key, limit)
+	if err != nil {
+		s.errorLog.Println(err)
+		return result, err
+	}
+
+	exactQuery := `SELECT COUNT(*)
+	FROM songs s
+	INNER JOIN groups g ON g.id = s.group_id
+	WHERE s.song_name ILIKE $1 ESCAPE '\' OR g.group_name ILIKE $1 ESCAPE '\'
+		OR s.search_key LIKE $2 ESCAPE '\' OR g.search_key LIKE $2 ESCAPE '\'`
+
+	err = tx.QueryRow(ctx, exactQuery, "%"+escapeLike(query)+"%", "%"+escapeLike(key)+"%").Scan(&result.ExactHits)
+	if err != nil {
+		s.errorLog.Println(err)
+		return result, err
+	}
+
+	if result.ExactHits == 0 {
+		suggestQuery := `SELECT name FROM (
+			SELECT song_name name FROM songs WHERE song_name % $1
+			UNION
+			SELECT group_name FROM groups WHERE group_name % $1
+		) names
+		ORDER BY similarity(name, $1) DESC, name
+		LIMIT 1`
+
+		var suggestions []string
+		err = pgxscan.Select(ctx, tx, &suggestions, suggestQuery, query)
+		if err != nil {
+			s.errorLog.Println(err)
+			return result, err
+		}
+		if len(suggestions) > 0 {
+			result.Suggestion = suggestions[0]
+		}
+	}
+
+	return result, tx.Commit(ctx)
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"context"
+	"effectiveMobile/models"
+	"fmt"
+	"strings"
+)
+
+// songChangeSets переводит изменения песни в присваивания UPDATE.
+// Даты передаются строками YYYY-MM-DD, их принимают и Postgres, и SQLite.
+// Вместе с датой всегда записывается её точность.
+func songChangeSets(changes models.SongChanges, b *whereBuilder) string {
+	var sets []string
+
+	if changes.Name.Set {
+		sets = append(sets,
+			"song_name = "+b.arg(changes.Name.Value),
+			"search_key = "+b.arg(searchKey(changes.Name.Value)),
+			"name_key = "+b.arg(nameKey(changes.Name.Value)))
+	}
+	if changes.GroupID.Set {
+		sets = append(sets, "group_id = "+b.arg(changes.GroupID.Value))
+	}
+	if changes.ReleaseDate.Set {
+		sets = append(sets,
+			"release_date = "+b.arg(dateString(changes.ReleaseDate.Value)),
+			"release_date_precision = "+b.arg(releasePrecision(changes.ReleaseDate.Value, changes.ReleaseDatePrecision)))
+	}
+	if changes.Text.Set {
+		sets = append(sets, "text = "+b.arg(changes.Text.Value))
+	}
+	if changes.Link.Set {
+		sets = append(sets, "link = "+b.arg(changes.Link.Value))
+	}
+	if changes.AlbumID.Set {
+		sets = append(sets, "album_id = "+b.arg(changes.AlbumID.Value))
+	}
+	if changes.DiscNumber.Set {
+		sets = append(sets, "disc_number = "+b.arg(changes.DiscNumber.Value))
+	}
+	if changes.TrackNumber.Set {
+		sets = append(sets, "track_number = "+b.arg(changes.TrackNumber.Value))
+	}
+
+	sets = append(sets, "version = version + 1")
+
+	return strings.Join(sets, ", ")
+}
+
+// PatchSong меняет только переданные поля песни одним запросом UPDATE
+func (s *songStorage) PatchSong(ctx context.Context, id int, changes models.SongChanges, version int) error {
+	s.infoLog.Print("Запускаем SQL запрос по частичному обновлению песни")
+
+	b := &whereBuilder{}
+	query := fmt.Sprintf("UPDATE songs SET %s WHERE id = %s AND %s",
+		songChangeSets(changes, b), b.arg(id), versionMatches(b.arg(version)))
+
+	tag, err := s.db.Exec(ctx, query, b.args...)
+	if err != nil {
+		s.errorLog.Println(err)
+		return mapPgError(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return s.songUnchanged(ctx, id, version)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"effectiveMobile/models"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+)
+
+var ErrInvalidSearch = errors.New("неправильный поисковый запрос")
+
+// searchConfig — конфигурация текстового поиска Postgres и столбец с векторами
+type searchConfig struct {
+	name   string
+	column string
+}
+
+var searchConfigs = map[string][]searchConfig{
+	models.SearchLangRu:  {{name: "russian", column: "s.search_ru"}},
+	models.SearchLangEn:  {{name: "english", column: "s.search_en"}},
+	models.SearchLangAny: {{name: "russian", column: "s.search_ru"}, {name: "english", column: "s.search_en"}},
+}
+
+const headlineOptions = "StartSel=<b>, StopSel=</b>, MaxFragments=2, MaxWords=20, MinWords=5"
+
+// SearchSongs ищет песни по названию, группе и тексту и сортирует их по ts_rank.
+// При поиске на обоих языках берётся лучшая из двух оценок.
+func (s *songStorage) SearchSongs(ctx context.Context, search models.SearchQuery, page models.Pagination) (models.SongSearchPage, error) {
+	s.infoLog.Print("Запускаем SQL запрос по полнотекстовому поиску песен")
+	result := models.SongSearchPage{Items: []models.SongSearchHit{}}
+
+	configs, ok := searchConfigs[search.Lang]
+	if !ok {
+		return result, ErrInvalidSearch
+	}
+
+	b := &whereBuilder{}
+	text := b.arg(search.Text)
+
+	matches := make([]string, len(configs))
+	ranks := make([]string, len(configs))
+	headlines := make([]string, len(configs))
+	for i, config := range configs {
+		tsquery := fmt.Sprintf("websearch_to_tsquery('%s', %s)", config.name, text)
+		matches[i] = fmt.Sprintf("%s @@ %s", config.column, tsquery)
+		ranks[i] = fmt.Sprintf("ts_rank(%s, %s)", config.column, tsquery)
+		headlines[i] = fmt.Sprintf("ts_headline('%s', concat_ws(E'\\n', s.song_name, s.text), %s, '%s')",
+			config.name, tsquery, headlineOptions)
+	}
+
+	rank := ranks[0]
+	if len(ranks) > 1 {
+		rank = "GREATEST(" + strings.Join(ranks, ", ") + ")"
+	}
+
+	// Фрагмент строим в конфигурации первого совпавшего языка
+	headline := headlines[len(headlines)-1]
+	for i := len(headlines) - 2; i >= 0; i-- {
+		headline = fmt.Sprintf("CASE WHEN %s THEN %s ELSE %s END", matches[i], headlines[i], headline)
+	}
+
+	b.add("(" + strings.Join(matches, " OR ") + ")")
+
+	countQuery := `SELECT COUNT(*)
+	FROM songs s
+	INNER JOIN groups g ON g.id = s.group_id
+	` + b.sql()
+
+	err := s.db.QueryRow(ctx, countQuery, b.args...).Scan(&result.Total)
+	if err != nil {
+		s.errorLog.Println(err)
+		return result, err
+	}
+
+	query := fmt.Sprintf(`SELECT s.id, song_name name, group_name, release_date, release_date_precision, link,
+		%s rank,
+		%s snippet
+	FROM songs s
+	INNER JOIN groups g ON g.id = s.group_id
+	%s
+	ORDER BY rank DESC, s.id
+	LIMIT %s OFFSET %s`, rank, headline, b.sql(), b.arg(page.Limit), b.arg((page.Page-1)*page.Limit))
+
+	err = pgxscan.Select(ctx, s.db, &result.Items, query, b.args...)
+	if err != nil {
+		s.errorLog.Println(err)
+	}
+
+	return result, err
+}
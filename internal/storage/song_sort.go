@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"cmp"
+	"effectiveMobile/models"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidSort = errors.New("неправильная сортировка")
+
+// sortColumn описывает поле сортировки: SQL выражение и значение ключа
+// сортировки у песни, которое попадает в курсор
+type sortColumn struct {
+	expr    string
+	numeric bool
+	key     func(song models.Song) string
+}
+
+// Белый список полей сортировки. Выражения не должны возвращать NULL,
+// иначе сравнение с курсором потеряет строки.
+var songSortColumns = map[string]sortColumn{
+	models.SortByID: {
+		expr:    "s.id",
+		numeric: true,
+		key:     func(song models.Song) string { return strconv.Itoa(derefInt(song.ID)) },
+	},
+	models.SortBySong: {
+		expr: "s.song_name",
+		key:  func(song models.Song) string { return derefString(song.Name) },
+	},
+	models.SortByGroup: {
+		expr: "g.group_name",
+		key:  func(song models.Song) string { return derefString(song.Group_name) },
+	},
+	models.SortByReleaseDate: {
+		// Postgres выводит DATE как YYYY-MM-DD, SQLite хранит даты строками
+		expr: "COALESCE(CAST(s.release_date AS TEXT), '')",
+		key: func(song models.Song) string {
+			if song.ReleaseDate == nil {
+				return ""
+			}
+			return song.ReleaseDate.Format("2006-01-02")
+		},
+	},
+}
+
+type sortKey struct {
+	models.SortField
+	column sortColumn
+}
+
+type songOrder []sortKey
+
+// resolveSongOrder проверяет поля сортировки и добавляет в конец id,
+// чтобы порядок был однозначным и курсор не пропускал песни
+func resolveSongOrder(fields []models.SortField) (songOrder, error) {
+	var order songOrder
+	hasID := false
+
+	for _, field := range fields {
+		column, ok := songSortColumns[field.Field]
+		if !ok {
+			return nil, ErrInvalidSort
+		}
+		if field.Field == models.SortByID {
+			hasID = true
+		}
+		order = append(order, sortKey{field, column})
+	}
+
+	if !hasID {
+		order = append(order, sortKey{models.SortField{Field: models.SortByID}, songSortColumns[models.SortByID]})
+	}
+
+	return order, nil
+}
+
+// signature — каноническая запись порядка, сохраняется в курсоре
+func (o songOrder) signature() string {
+	parts := make([]string, len(o))
+	for i, field := range o {
+		direction := "asc"
+		if field.Desc {
+			direction = "desc"
+		}
+		parts[i] = field.Field + ":" + direction
+	}
+	return strings.Join(parts, ",")
+}
+
+func (o songOrder) orderBy() string {
+	parts := make([]string, len(o))
+	for i, field := range o {
+		direction := "ASC"
+		if field.Desc {
+			direction = "DESC"
+		}
+		parts[i] = field.column.expr + " " + direction
+	}
+	return "ORDER BY " + strings.Join(parts, ", ")
+}
+
+// keys возвращает значения ключей сортировки песни
+func (o songOrder) keys(song models.Song) []string {
+	values := make([]string, len(o))
+	for i, field := range o {
+		values[i] = field.column.key(song)
+	}
+	return values
+}
+
+func (o songOrder) cursor(song models.Song) string {
+	return encodeCursor(songCursor{Sort: o.signature(), Values: o.keys(song)})
+}
+
+// checkCursor проверяет, что курсор выдан для этого же порядка
+func (o songOrder) checkCursor(c songCursor) error {
+	if c.Sort != o.signature() || len(c.Values) != len(o) {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+// compare сравнивает ключи сортировки двух песен с учётом направлений
+func (o songOrder) compare(a, b []string) int {
+	for i, field := range o {
+		result := strings.Compare(a[i], b[i])
+		if field.column.numeric {
+			left, _ := strconv.Atoi(a[i])
+			right, _ := strconv.Atoi(b[i])
+			result = cmp.Compare(left, right)
+		}
+
+		if field.Desc {
+			result = -result
+		}
+		if result != 0 {
+			return result
+		}
+	}
+	return 0
+}
+
+// after строит условие "строка идёт после курсора" для произвольного
+// сочетания направлений: (a > va) OR (a = va AND b < vb) OR ...
+func (o songOrder) after(c songCursor, b *whereBuilder) (string, error) {
+	if err := o.checkCursor(c); err != nil {
+		return "", err
+	}
+
+	values := make([]any, len(o))
+	for i, field := range o {
+		if !field.column.numeric {
+			values[i] = c.Values[i]
+			continue
+		}
+		number, err := strconv.Atoi(c.Values[i])
+		if err != nil {
+			return "", ErrInvalidCursor
+		}
+		values[i] = number
+	}
+
+	var alternatives []string
+	for i, field := range o {
+		var conds []string
+		for j := 0; j < i; j++ {
+			conds = append(conds, fmt.Sprintf("%s = %s", o[j].column.expr, b.arg(values[j])))
+		}
+
+		op := ">"
+		if field.Desc {
+			op = "<"
+		}
+		conds = append(conds, fmt.Sprintf("%s %s %s", field.column.expr, op, b.arg(values[i])))
+
+		alternatives = append(alternatives, "("+strings.Join(conds, " AND ")+")")
+	}
+
+	return "(" + strings.Join(alternatives, " OR ") + ")", nil
+}
+
+func derefInt(value *int) int {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
+
+func derefString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
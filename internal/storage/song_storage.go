@@ -3,28 +3,44 @@ package storage
 import (
 	"context"
 	"effectiveMobile/models"
+	"fmt"
 	"log"
+	"time"
 
 	"github.com/georgysavva/scany/v2/pgxscan"
-	"github.com/jackc/pgx/v5"
 )
 
 type SongStorage interface {
-	GetAllSongs(ctx context.Context, filterName, ilterGroup string) ([]models.Song, error)
+	GetAllSongs(ctx context.Context, filter models.SongFilter, page models.Pagination) (models.SongPage, error)
 	GetSongByID(ctx context.Context, id int) (models.Song, error)
-	AddSong(ctx context.Context, song models.Song) error
-	UpdateSong(ctx context.Context, id int, song models.Song) error
-	DeleteSong(ctx context.Context, id int) error
+	SearchSongs(ctx context.Context, search models.SearchQuery, page models.Pagination) (models.SongSearchPage, error)
+	FuzzySearchSongs(ctx context.Context, query string, threshold float64, limit int) (models.FuzzySongPage, error)
+	AddSong(ctx context.Context, song models.Song) (int, error)
+	// FindSongByName ищет песню группы, название которой совпадает с name
+	// так же, как при проверке уникальности. Если её нет, возвращает ErrNotFound.
+	FindSongByName(ctx context.Context, groupID int, name string) (int, error)
+	// version — ожидаемая версия песни (AnyVersion отключает проверку).
+	// Изменение увеличивает версию, при несовпадении возвращается ErrVersionMismatch.
+	UpdateSong(ctx context.Context, id int, song models.Song, version int) error
+	PatchSong(ctx context.Context, id int, changes models.SongChanges, version int) error
+	DeleteSong(ctx context.Context, id int, version int) error
 	AddGroup(ctx context.Context, group models.Group) (int, error)
+	GetIncompleteSongs(ctx context.Context, limit, maxAttempts int, retryDelay time.Duration) ([]models.Song, error)
+	SaveEnrichment(ctx context.Context, id int, song models.Song) error
+	MarkEnrichmentFailed(ctx context.Context, id int, reason string) error
+	RefreshSearchKeys(ctx context.Context) (int, error)
+	GroupStorage
+	AlbumStorage
+	UnitOfWork
 }
 
 type songStorage struct {
-	db       *pgx.Conn
+	db       DB
 	infoLog  *log.Logger
 	errorLog *log.Logger
 }
 
-func NewSongStorage(db *pgx.Conn, infoLog, errorLog *log.Logger) SongStorage {
+func NewSongStorage(db DB, infoLog, errorLog *log.Logger) SongStorage {
 	return &songStorage{
 		db:       db,
 		infoLog:  infoLog,
@@ -32,109 +48,304 @@ func NewSongStorage(db *pgx.Conn, infoLog, errorLog *log.Logger) SongStorage {
 	}
 }
 
-func (s *songStorage) GetAllSongs(ctx context.Context, filterName, filterGroup string) ([]models.Song, error) {
+// GetAllSongs возвращает страницу песен в порядке page.Sort. При заданном
+// курсоре выдаются песни после него (keyset), иначе используется смещение
+// по номеру страницы.
+func (s *songStorage) GetAllSongs(ctx context.Context, filter models.SongFilter, page models.Pagination) (models.SongPage, error) {
 	s.infoLog.Print("Запускаем SQL запрос по получению всех песен")
-	var songs []models.Song
-	var err error
+	result := models.SongPage{Items: []models.Song{}}
+
+	order, err := resolveSongOrder(page.Sort)
+	if err != nil {
+		return result, err
+	}
+
+	b := buildSongWhere(filter, postgresILike)
 
-	query := `SELECT song_name name, group_name, release_date, link 
+	countQuery := `SELECT COUNT(*)
 					FROM songs s
 					INNER JOIN groups g ON g.id = s.group_id
-					WHERE s.song_name LIKE '%' || $1  || '%' AND group_name LIKE '%' || $2  || '%'`
+					` + b.sql()
 
-	err = pgxscan.Select(context.Background(), s.db, &songs, query, filterName, filterGroup)
+	err = s.db.QueryRow(ctx, countQuery, b.args...).Scan(&result.Total)
 	if err != nil {
 		s.errorLog.Println(err)
+		return result, err
 	}
 
-	return songs, err
+	offset := 0
+	if page.After != "" {
+		cursor, err := decodeCursor(page.After)
+		if err != nil {
+			return result, err
+		}
+		cond, err := order.after(cursor, b)
+		if err != nil {
+			return result, err
+		}
+		b.add(cond)
+	} else {
+		offset = (page.Page - 1) * page.Limit
+	}
+
+	// Запрашиваем на одну песню больше, чтобы понять, есть ли следующая страница
+	query := fmt.Sprintf(`SELECT s.id, song_name name, group_name, release_date, release_date_precision, link,
+					album_id, disc_number, track_number
+					FROM songs s
+					INNER JOIN groups g ON g.id = s.group_id
+					%s
+					%s
+					LIMIT %s OFFSET %s`, b.sql(), order.orderBy(), b.arg(page.Limit+1), b.arg(offset))
+
+	err = pgxscan.Select(ctx, s.db, &result.Items, query, b.args...)
+	if err != nil {
+		s.errorLog.Println(err)
+		return result, err
+	}
+
+	if len(result.Items) > page.Limit {
+		result.Items = result.Items[:page.Limit]
+		last := result.Items[len(result.Items)-1]
+		result.NextCursor = order.cursor(last)
+	}
+
+	return result, nil
 }
 
 func (s *songStorage) GetSongByID(ctx context.Context, id int) (models.Song, error) {
 	s.infoLog.Print("Запускаем SQL запрос по получению песни по ID")
-	query := `SELECT song_name name, group_name, release_date, text, link 
+	query := `SELECT s.id, song_name name, group_name, release_date, release_date_precision, text, link,
+		album_id, disc_number, track_number, version
 	FROM songs s
 	INNER JOIN groups g ON g.id = s.group_id
 	WHERE s.id = $1`
 
-	var song []models.Song
-	err := pgxscan.Select(context.Background(), s.db, &song, query, id)
+	var song models.Song
+	err := pgxscan.Get(ctx, s.db, &song, query, id)
+	if pgxscan.NotFound(err) {
+		return song, ErrNotFound
+	}
 	if err != nil {
 		s.errorLog.Println(err)
 	}
 
-	return song[0], err
+	return song, err
 }
 
-func (s *songStorage) DeleteSong(ctx context.Context, id int) error {
+func (s *songStorage) DeleteSong(ctx context.Context, id int, version int) error {
 	s.infoLog.Print("Запускаем SQL запрос по удалению песни по ID")
-	query := `DELETE FROM songs WHERE id = $1`
+	query := `DELETE FROM songs WHERE id = $1 AND ` + versionMatches("$2")
 
-	_, err := s.db.Exec(context.Background(), query, id)
+	tag, err := s.db.Exec(ctx, query, id, version)
 	if err != nil {
 		s.errorLog.Println(err)
+		return err
 	}
 
-	return err
+	if tag.RowsAffected() == 0 {
+		return s.songUnchanged(ctx, id, version)
+	}
+	return nil
 }
 
-func (s *songStorage) AddSong(ctx context.Context, song models.Song) error {
+// AddSong добавляет песню и возвращает её ID. Если у группы уже есть песня
+// с таким же названием без учёта регистра и пробелов, возвращает
+// *ConflictError с её ID. ON CONFLICT вместо ошибки ограничения
+// не прерывает транзакцию, в которой выполняется запрос.
+func (s *songStorage) AddSong(ctx context.Context, song models.Song) (int, error) {
 	s.infoLog.Print("Запускаем SQL запрос по добавлению песни")
-	query := "INSERT INTO songs (group_id, song_name) VALUES ($1, $2)"
-	_, err := s.db.Exec(
-		context.Background(),
+	query := `INSERT INTO songs (group_id, song_name, search_key, name_key, release_date, release_date_precision, text, link, needs_enrichment)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	ON CONFLICT (group_id, name_key) DO NOTHING
+	RETURNING id`
+
+	var id int
+	err := s.db.QueryRow(
+		ctx,
 		query,
-		song.Group, song.Name,
-	)
+		song.Group, song.Name, searchKey(song.Name), nameKey(song.Name), dateString(song.ReleaseDate),
+		releasePrecision(song.ReleaseDate, song.ReleaseDatePrecision), song.Text, song.Link, song.NeedsEnrichment,
+	).Scan(&id)
+	if pgxscan.NotFound(err) {
+		return 0, s.songConflict(ctx, song)
+	}
 	if err != nil {
 		s.errorLog.Println(err)
 	}
-	return err
+	return id, mapPgError(err)
 }
 
-func (s *songStorage) AddGroup(ctx context.Context, group models.Group) (int, error) {
-	var groupID int
-
-	s.infoLog.Print("Запускаем SQL запрос по добавлению группы")
+// songConflict находит песню, из-за которой не удалось добавить song
+func (s *songStorage) songConflict(ctx context.Context, song models.Song) error {
+	var id int
+	err := s.db.QueryRow(ctx, existingSongQuery, song.Group, nameKey(song.Name)).Scan(&id)
+	if pgxscan.NotFound(err) {
+		// Песню успели удалить
+		return ErrConflict
+	}
+	if err != nil {
+		s.errorLog.Println(err)
+		return err
+	}
+	return &ConflictError{ID: id}
+}
 
-	// Сначала проверим, существует ли уже такая группа (артист)
-	query := `SELECT id FROM groups WHERE group_name = $1`
-	err := s.db.QueryRow(ctx, query, group.Name).Scan(&groupID)
+func (s *songStorage) FindSongByName(ctx context.Context, groupID int, name string) (int, error) {
+	s.infoLog.Print("Запускаем SQL запрос по поиску песни группы по названию")
 
-	if err == nil {
-		// Артист уже существует, возвращаем его ID
-		return groupID, nil
+	var id int
+	err := s.db.QueryRow(ctx, existingSongQuery, groupID, nameKey(&name)).Scan(&id)
+	if pgxscan.NotFound(err) {
+		return 0, ErrNotFound
 	}
-
-	if err != pgx.ErrNoRows {
+	if err != nil {
 		s.errorLog.Println(err)
-		// Произошла ошибка, отличная от "запись не найдена"
-		return 0, err
 	}
+	return id, err
+}
 
-	query = "INSERT INTO groups(group_name) VALUES ($1) RETURNING id"
-	err = s.db.QueryRow(
-		context.Background(),
-		query,
-		group.Name,
-	).Scan(&groupID)
+// AddGroup возвращает ID группы, создавая её, если такой ещё нет. Один запрос
+// без предварительного SELECT не даёт двум параллельным вызовам столкнуться
+// на ограничении UNIQUE: DO UPDATE нужен, чтобы RETURNING вернул и существующую строку.
+func (s *songStorage) AddGroup(ctx context.Context, group models.Group) (int, error) {
+	s.infoLog.Print("Запускаем SQL запрос по добавлению группы")
+
+	// Группа с тем же названием без учёта регистра и пробелов уже есть
+	var groupID int
+	err := s.db.QueryRow(ctx, groupByNameKeyQuery, nameKey(group.Name), group.Name).Scan(&groupID)
+	if err == nil || !pgxscan.NotFound(err) {
+		if err != nil {
+			s.errorLog.Println(err)
+		}
+		return groupID, err
+	}
+
+	query := `INSERT INTO groups (group_name, search_key, name_key) VALUES ($1, $2, $3)
+	ON CONFLICT (group_name) DO UPDATE SET group_name = EXCLUDED.group_name
+	RETURNING id`
+
+	err = s.db.QueryRow(ctx, query, group.Name, searchKey(group.Name), nameKey(group.Name)).Scan(&groupID)
 	if err != nil {
 		s.errorLog.Println(err)
 	}
 	return groupID, err
 }
 
-func (s *songStorage) UpdateSong(ctx context.Context, id int, newSong models.Song) error {
+func (s *songStorage) UpdateSong(ctx context.Context, id int, newSong models.Song, version int) error {
 	s.infoLog.Print("Запускаем SQL запрос по обновлению песни по ID")
 
-	query := "UPDATE songs SET song_name = $1, text = $2, link = $3 WHERE id = $4"
-	_, err := s.db.Exec(
-		context.Background(),
+	query := `UPDATE songs SET song_name = $1, search_key = $2, name_key = $3, text = $4, link = $5, version = version + 1
+	WHERE id = $6 AND ` + versionMatches("$7")
+	tag, err := s.db.Exec(
+		ctx,
 		query,
-		newSong.Name, newSong.Text, newSong.Link, id,
+		newSong.Name, searchKey(newSong.Name), nameKey(newSong.Name), newSong.Text, newSong.Link, id, version,
 	)
 	if err != nil {
 		s.errorLog.Println(err)
+		return mapPgError(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return s.songUnchanged(ctx, id, version)
+	}
+	return nil
+}
+
+// songUnchanged объясняет, почему запрос не изменил песню:
+// её нет или у неё другая версия
+func (s *songStorage) songUnchanged(ctx context.Context, id int, version int) error {
+	if version == AnyVersion {
+		return ErrNotFound
+	}
+
+	var exists bool
+	err := s.db.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM songs WHERE id = $1)", id).Scan(&exists)
+	if err != nil {
+		s.errorLog.Println(err)
+		return err
+	}
+
+	return versionError(exists)
+}
+
+// enrichPartialReason — причина повторной попытки, когда внешний API
+// вернул песню без текста или ссылки
+const enrichPartialReason = "неполные данные: нет текста или ссылки"
+
+// GetIncompleteSongs возвращает песни без текста или ссылки, а также песни,
+// сохранённые без обогащения. Песня с attempts неудачными попытками
+// пропускается, пока не пройдёт retryDelay * 2^attempts с последней попытки.
+func (s *songStorage) GetIncompleteSongs(ctx context.Context, limit, maxAttempts int, retryDelay time.Duration) ([]models.Song, error) {
+	s.infoLog.Print("Запускаем SQL запрос по получению неполных песен")
+	query := `SELECT s.id, song_name name, group_name
+	FROM songs s
+	INNER JOIN groups g ON g.id = s.group_id
+	WHERE (s.text IS NULL OR s.link IS NULL OR s.needs_enrichment)
+		AND s.enrich_attempts < $1
+		AND (s.enrich_last_attempt IS NULL
+			OR s.enrich_last_attempt < NOW() - make_interval(secs => $2 * power(2, s.enrich_attempts)))
+	ORDER BY s.enrich_last_attempt NULLS FIRST, s.id
+	LIMIT $3`
+
+	var songs []models.Song
+	err := pgxscan.Select(ctx, s.db, &songs, query, maxAttempts, retryDelay.Seconds(), limit)
+	if err != nil {
+		s.errorLog.Println(err)
+	}
+
+	return songs, err
+}
+
+// SaveEnrichment заполняет недостающие поля песни. Счётчик попыток
+// сбрасывается, только если у песни появились и текст, и ссылка: иначе
+// попытка считается неудачной, чтобы песня не запрашивалась заново без паузы.
+func (s *songStorage) SaveEnrichment(ctx context.Context, id int, song models.Song) error {
+	s.infoLog.Print("Запускаем SQL запрос по сохранению обогащения песни")
+	query := `UPDATE songs SET
+		release_date = COALESCE($1::date, release_date),
+		release_date_precision = CASE WHEN $1::date IS NULL THEN release_date_precision ELSE $5 END,
+		text = COALESCE(text, $2),
+		link = COALESCE(link, $3),
+		needs_enrichment = FALSE,
+		enrich_attempts = CASE WHEN COALESCE(text, $2) IS NOT NULL AND COALESCE(link, $3) IS NOT NULL
+			THEN 0 ELSE enrich_attempts + 1 END,
+		enrich_last_error = CASE WHEN COALESCE(text, $2) IS NOT NULL AND COALESCE(link, $3) IS NOT NULL
+			THEN NULL ELSE $6 END,
+		enrich_last_attempt = NOW(),
+		version = version + 1
+	WHERE id = $4`
+
+	tag, err := s.db.Exec(ctx, query, dateString(song.ReleaseDate), song.Text, song.Link, id,
+		releasePrecision(song.ReleaseDate, song.ReleaseDatePrecision), enrichPartialReason)
+	if err != nil {
+		s.errorLog.Println(err)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// MarkEnrichmentFailed увеличивает счётчик неудачных попыток и запоминает причину
+func (s *songStorage) MarkEnrichmentFailed(ctx context.Context, id int, reason string) error {
+	s.infoLog.Print("Запускаем SQL запрос по отметке неудачного обогащения песни")
+	query := `UPDATE songs SET
+		enrich_attempts = enrich_attempts + 1,
+		enrich_last_error = $1,
+		enrich_last_attempt = NOW()
+	WHERE id = $2`
+
+	tag, err := s.db.Exec(ctx, query, reason, id)
+	if err != nil {
+		s.errorLog.Println(err)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
 	}
-	return err
+	return nil
 }
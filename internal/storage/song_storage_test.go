@@ -0,0 +1,47 @@
+package storage_test
+
+import (
+	"context"
+	"effectiveMobile/internal/migrations"
+	"effectiveMobile/internal/storage"
+	"effectiveMobile/internal/storage/storagetest"
+	"io"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TestPostgresSongStorage запускается только при заданной POSTGRES_TEST_URL:
+// база очищается перед каждой проверкой, поэтому рабочую указывать нельзя
+func TestPostgresSongStorage(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_URL")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_URL не задана")
+	}
+
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatalf("подключение к Postgres: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	list, err := migrations.Postgres()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrations.NewMigrator(pool, list, logger).Up(ctx); err != nil {
+		t.Fatalf("миграции: %v", err)
+	}
+
+	storagetest.RunSongStorageContract(t, func(t *testing.T) storage.SongStorage {
+		if _, err := pool.Exec(ctx, "TRUNCATE songs, groups RESTART IDENTITY CASCADE"); err != nil {
+			t.Fatalf("очистка базы: %v", err)
+		}
+		return storage.NewSongStorage(pool, logger, logger)
+	})
+}
@@ -0,0 +1,18 @@
+package storage
+
+// AnyVersion отключает проверку версии при изменении песни
+const AnyVersion = 0
+
+// versionMatches возвращает условие на версию песни для параметра param.
+// Параметр, равный AnyVersion, подходит к любой версии.
+func versionMatches(param string) string {
+	return "(" + param + " = 0 OR version = " + param + ")"
+}
+
+// versionError выбирает ошибку для запроса, который не изменил ни одной строки
+func versionError(exists bool) error {
+	if exists {
+		return ErrVersionMismatch
+	}
+	return ErrNotFound
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Функции, которых нет в SQLite: fold приводит строку к нижнему регистру
+// с учётом Unicode (встроенная lower понимает только ASCII), similarity
+// считает сходство по триграммам так же, как pg_trgm.
+func init() {
+	sqlite.MustRegisterDeterministicScalarFunction("fold", 1,
+		func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			value, ok := args[0].(string)
+			if !ok {
+				return args[0], nil
+			}
+			return strings.ToLower(value), nil
+		})
+
+	sqlite.MustRegisterDeterministicScalarFunction("similarity", 2,
+		func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			left, _ := args[0].(string)
+			right, _ := args[1].(string)
+			return similarity(left, right), nil
+		})
+}
+
+// OpenSQLite открывает файл базы SQLite, создавая его при необходимости.
+// Журнал WAL позволяет читать во время записи, а busy_timeout — ждать
+// освобождения базы вместо ошибки SQLITE_BUSY. Транзакции сразу берут
+// блокировку записи (_txlock=immediate): отложенная транзакция, начавшая
+// с чтения, получила бы SQLITE_BUSY без ожидания при попытке записать.
+func OpenSQLite(path string) (*sql.DB, error) {
+	dsn := fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate", path)
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func sqliteILike(expr, pattern string) string {
+	return fmt.Sprintf(`fold(%s) LIKE fold(%s) ESCAPE '\'`, expr, pattern)
+}
+
+// mapSQLiteError переводит нарушения ограничений SQLite в ошибки хранилища,
+// остальные ошибки возвращает без изменений
+func mapSQLiteError(err error) error {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return err
+	}
+
+	switch sqliteErr.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		return ErrConflict
+	case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+		return ErrNotFound
+	}
+
+	return err
+}
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"context"
+	"effectiveMobile/models"
+	"fmt"
+
+	"github.com/georgysavva/scany/v2/sqlscan"
+)
+
+func (s *sqliteStorage) GetAllAlbums(ctx context.Context, filter models.AlbumFilter, page models.Pagination) (models.AlbumPage, error) {
+	s.infoLog.Print("Запускаем SQL запрос по получению альбомов")
+	result := models.AlbumPage{Items: []models.Album{}}
+
+	b := buildAlbumWhere(filter, sqliteILike)
+
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM albums a "+b.sql(), b.args...).Scan(&result.Total)
+	if err != nil {
+		s.errorLog.Println(err)
+		return result, err
+	}
+
+	query := fmt.Sprintf(`SELECT %s
+	%s
+	%s
+	%s
+	LIMIT %s OFFSET %s`, albumColumns, albumFrom, b.sql(), albumOrder, b.arg(page.Limit), b.arg((page.Page-1)*page.Limit))
+
+	err = sqlscan.Select(ctx, s.db, &result.Items, query, b.args...)
+	if err != nil {
+		s.errorLog.Println(err)
+	}
+
+	return result, err
+}
+
+func (s *sqliteStorage) GetAlbumByID(ctx context.Context, id int) (models.Album, error) {
+	s.infoLog.Print("Запускаем SQL запрос по получению альбома по ID")
+	query := `SELECT ` + albumColumns + ` ` + albumFrom + ` WHERE a.id = $1`
+
+	var album models.Album
+	err := sqlscan.Get(ctx, s.db, &album, query, id)
+	if sqlscan.NotFound(err) {
+		return album, ErrNotFound
+	}
+	if err != nil {
+		s.errorLog.Println(err)
+	}
+
+	return album, err
+}
+
+func (s *sqliteStorage) GetAlbumTracks(ctx context.Context, id int) ([]models.Song, error) {
+	s.infoLog.Print("Запускаем SQL запрос по получению песен альбома")
+
+	tracks := []models.Song{}
+	err := sqlscan.Select(ctx, s.db, &tracks, albumTracksQuery, id)
+	if err != nil {
+		s.errorLog.Println(err)
+	}
+
+	return tracks, err
+}
+
+func (s *sqliteStorage) CreateAlbum(ctx context.Context, album models.Album) (int, error) {
+	s.infoLog.Print("Запускаем SQL запрос по созданию альбома")
+	query := `INSERT INTO albums (title, search_key, group_id, release_date, release_date_precision, album_type, cover)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING id`
+
+	var id int
+	err := s.db.QueryRowContext(ctx, query,
+		album.Title, searchKey(album.Title), album.Group, dateString(album.ReleaseDate),
+		releasePrecision(album.ReleaseDate, album.ReleaseDatePrecision), string(album.Type), album.Cover,
+	).Scan(&id)
+	if err != nil {
+		s.errorLog.Println(err)
+	}
+	return id, mapSQLiteError(err)
+}
+
+func (s *sqliteStorage) UpdateAlbum(ctx context.Context, id int, album models.Album) error {
+	s.infoLog.Print("Запускаем SQL запрос по обновлению альбома")
+	query := `UPDATE albums SET title = $1, search_key = $2, group_id = $3, release_date = $4,
+		release_date_precision = $5, album_type = $6, cover = $7
+	WHERE id = $8`
+
+	result, err := s.db.ExecContext(ctx, query,
+		album.Title, searchKey(album.Title), album.Group, dateString(album.ReleaseDate),
+		releasePrecision(album.ReleaseDate, album.ReleaseDatePrecision), string(album.Type), album.Cover, id)
+	if err != nil {
+		s.errorLog.Println(err)
+		return mapSQLiteError(err)
+	}
+
+	return s.checkAffected(result)
+}
+
+func (s *sqliteStorage) DeleteAlbum(ctx context.Context, id int) error {
+	s.infoLog.Print("Запускаем SQL запрос по удалению альбома")
+
+	return s.inTx(ctx, func(tx *sqliteStorage) error {
+		if _, err := tx.db.ExecContext(ctx, detachAlbumSongsQuery, id); err != nil {
+			tx.errorLog.Println(err)
+			return err
+		}
+
+		result, err := tx.db.ExecContext(ctx, "DELETE FROM albums WHERE id = $1", id)
+		if err != nil {
+			tx.errorLog.Println(err)
+			return err
+		}
+
+		return tx.checkAffected(result)
+	})
+}
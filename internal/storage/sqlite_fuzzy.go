@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"effectiveMobile/internal/searchkey"
+	"effectiveMobile/models"
+
+	"github.com/georgysavva/scany/v2/sqlscan"
+)
+
+// FuzzySearchSongs повторяет нечёткий поиск Postgres с функцией similarity,
+// зарегистрированной в драйвере. Индексов триграмм в SQLite нет, поэтому
+// сравниваются все песни — для небольших библиотек этого достаточно.
+func (s *sqliteStorage) FuzzySearchSongs(ctx context.Context, query string, threshold float64, limit int) (models.FuzzySongPage, error) {
+	s.infoLog.Print("Запускаем SQL запрос по нечёткому поиску песен")
+	result := models.FuzzySongPage{Items: []models.FuzzySongHit{}}
+	key := searchkey.Key(query)
+
+	searchQuery := `SELECT id, name, group_name, release_date, release_date_precision, link, score FROM (
+		SELECT s.id, song_name name, group_name, s.release_date, s.release_date_precision, link,
+			MAX(
+				similarity(s.song_name, $1),
+				similarity(g.group_name, $1),
+				similarity(g.group_name || ' ' || s.song_name, $1),
+				similarity(s.search_key, $2),
+				similarity(g.search_key, $2)
+			) score
+		FROM songs s
+		INNER JOIN groups g ON g.id = s.group_id
+	)
+	WHERE score >= $3
+	ORDER BY score DESC, id
+	LIMIT $4`
+
+	err := sqlscan.Select(ctx, s.db, &result.Items, searchQuery, query, key, threshold, limit)
+	if err != nil {
+		s.errorLog.Println(err)
+		return result, err
+	}
+
+	exactQuery := `SELECT COUNT(*)
+	FROM songs s
+	INNER JOIN groups g ON g.id = s.group_id
+	WHERE ` + sqliteILike("s.song_name", "$1") + ` OR ` + sqliteILike("g.group_name", "$1") + `
+		OR s.search_key LIKE $2 ESCAPE '\' OR g.search_key LIKE $2 ESCAPE '\'`
+
+	err = s.db.QueryRowContext(ctx, exactQuery, "%"+escapeLike(query)+"%", "%"+escapeLike(key)+"%").Scan(&result.ExactHits)
+	if err != nil {
+		s.errorLog.Println(err)
+		return result, err
+	}
+
+	if result.ExactHits == 0 {
+		suggestQuery := `SELECT name FROM (
+			SELECT song_name name FROM songs
+			UNION
+			SELECT group_name FROM groups
+		)
+		WHERE similarity(name, $1) >= $2
+		ORDER BY similarity(name, $1) DESC, name
+		LIMIT 1`
+
+		var suggestions []string
+		err = sqlscan.Select(ctx, s.db, &suggestions, suggestQuery, query, threshold)
+		if err != nil {
+			s.errorLog.Println(err)
+			return result, err
+		}
+		if len(suggestions) > 0 {
+			result.Suggestion = suggestions[0]
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"effectiveMobile/models"
+	"errors"
+	"fmt"
+
+	"github.com/georgysavva/scany/v2/sqlscan"
+)
+
+// sqliteHasGenre проверяет, что массив JSON жанров группы содержит жанр
+func sqliteHasGenre(genre string) string {
+	return fmt.Sprintf("EXISTS (SELECT 1 FROM json_each(g.genres) WHERE json_each.value = %s)", genre)
+}
+
+func (s *sqliteStorage) GetAllGroups(ctx context.Context, filter models.GroupFilter, page models.Pagination) (models.GroupPage, error) {
+	s.infoLog.Print("Запускаем SQL запрос по получению групп")
+	result := models.GroupPage{Items: []models.Group{}}
+
+	b := buildGroupWhere(filter, sqliteILike, sqliteHasGenre)
+
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM groups g "+b.sql(), b.args...).Scan(&result.Total)
+	if err != nil {
+		s.errorLog.Println(err)
+		return result, err
+	}
+
+	query := fmt.Sprintf(`SELECT %s
+	FROM groups g
+	%s
+	ORDER BY g.group_name, g.id
+	LIMIT %s OFFSET %s`, groupColumns, b.sql(), b.arg(page.Limit), b.arg((page.Page-1)*page.Limit))
+
+	err = sqlscan.Select(ctx, s.db, &result.Items, query, b.args...)
+	if err != nil {
+		s.errorLog.Println(err)
+	}
+
+	return result, err
+}
+
+func (s *sqliteStorage) GetGroupByID(ctx context.Context, id int) (models.Group, error) {
+	s.infoLog.Print("Запускаем SQL запрос по получению группы по ID")
+	query := `SELECT ` + groupColumns + ` FROM groups g WHERE g.id = $1`
+
+	var group models.Group
+	err := sqlscan.Get(ctx, s.db, &group, query, id)
+	if sqlscan.NotFound(err) {
+		return group, ErrNotFound
+	}
+	if err != nil {
+		s.errorLog.Println(err)
+	}
+
+	return group, err
+}
+
+func (s *sqliteStorage) CreateGroup(ctx context.Context, group models.Group) (int, error) {
+	s.infoLog.Print("Запускаем SQL запрос по созданию группы")
+	query := `INSERT INTO groups (group_name, search_key, name_key, country, formed_year, genres, bio)
+	VALUES ($1, $2, $7, $3, $4, $5, $6)
+	ON CONFLICT (group_name) DO NOTHING
+	RETURNING id`
+
+	var id int
+	err := s.db.QueryRowContext(ctx, query,
+		group.Name, searchKey(group.Name), group.Country, group.FormedYear, group.Genres.JSON(), group.Bio, nameKey(group.Name),
+	).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		if err := s.groupConflict(ctx, group.Name, 0); err != nil {
+			return 0, err
+		}
+		return 0, ErrConflict
+	}
+	if err != nil {
+		s.errorLog.Println(err)
+	}
+	return id, err
+}
+
+func (s *sqliteStorage) groupConflict(ctx context.Context, name *string, exceptID int) error {
+	var id int
+	err := s.db.QueryRowContext(ctx, existingGroupQuery, name, exceptID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		s.errorLog.Println(err)
+		return err
+	}
+	return &ConflictError{ID: id}
+}
+
+func (s *sqliteStorage) UpdateGroup(ctx context.Context, id int, group models.Group) error {
+	s.infoLog.Print("Запускаем SQL запрос по обновлению группы")
+
+	return s.inTx(ctx, func(tx *sqliteStorage) error {
+		if err := tx.groupConflict(ctx, group.Name, id); err != nil {
+			return err
+		}
+
+		query := `UPDATE groups SET group_name = $1, search_key = $2, country = $3, formed_year = $4,
+			genres = $5, bio = $6, name_key = $8
+		WHERE id = $7`
+
+		result, err := tx.db.ExecContext(ctx, query,
+			group.Name, searchKey(group.Name), group.Country, group.FormedYear, group.Genres.JSON(), group.Bio, id, nameKey(group.Name))
+		if err != nil {
+			tx.errorLog.Println(err)
+			return mapSQLiteError(err)
+		}
+		if err := tx.checkAffected(result); err != nil {
+			return err
+		}
+
+		if _, err := tx.db.ExecContext(ctx, touchGroupSongsQuery, id); err != nil {
+			tx.errorLog.Println(err)
+			return err
+		}
+		return nil
+	})
+}
+
+func (s *sqliteStorage) DeleteGroup(ctx context.Context, id int, cascade bool) error {
+	s.infoLog.Print("Запускаем SQL запрос по удалению группы")
+
+	return s.inTx(ctx, func(tx *sqliteStorage) error {
+		if cascade {
+			if _, err := tx.db.ExecContext(ctx, "DELETE FROM songs WHERE group_id = $1", id); err != nil {
+				tx.errorLog.Println(err)
+				return err
+			}
+		}
+		if _, err := tx.db.ExecContext(ctx, detachGroupAlbumsQuery, id); err != nil {
+			tx.errorLog.Println(err)
+			return err
+		}
+
+		// Транзакция SQLite держит блокировку записи, поэтому песня
+		// не может появиться между проверкой и удалением
+		query := `DELETE FROM groups WHERE id = $1
+			AND NOT EXISTS (SELECT 1 FROM songs WHERE group_id = $1)`
+		result, err := tx.db.ExecContext(ctx, query, id)
+		if err != nil {
+			tx.errorLog.Println(err)
+			return err
+		}
+
+		if err := tx.checkAffected(result); !errors.Is(err, ErrNotFound) {
+			return err
+		}
+
+		var exists bool
+		err = tx.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM groups WHERE id = $1)", id).Scan(&exists)
+		if err != nil {
+			tx.errorLog.Println(err)
+			return err
+		}
+		if exists {
+			return ErrGroupNotEmpty
+		}
+		return ErrNotFound
+	})
+}
+
+func (s *sqliteStorage) AliasGroup(ctx context.Context, id, targetID int) error {
+	s.infoLog.Print("Запускаем SQL запрос по замене группы псевдонимом")
+
+	return s.inTx(ctx, func(tx *sqliteStorage) error {
+		// Альбомы и псевдонимы переносятся до удаления группы, иначе их удалит внешний ключ
+		_, err := tx.db.ExecContext(ctx, "UPDATE albums SET group_id = $2 WHERE group_id = $1", id, targetID)
+		if err == nil {
+			_, err = tx.db.ExecContext(ctx, "UPDATE group_aliases SET group_id = $2 WHERE group_id = $1", id, targetID)
+		}
+		if err == nil {
+			_, err = tx.db.ExecContext(ctx, "INSERT INTO group_aliases (id, group_id) VALUES ($1, $2)", id, targetID)
+		}
+		if err != nil {
+			tx.errorLog.Println(err)
+			return mapSQLiteError(err)
+		}
+
+		return tx.DeleteGroup(ctx, id, false)
+	})
+}
+
+func (s *sqliteStorage) ResolveGroupAlias(ctx context.Context, id int) (int, error) {
+	s.infoLog.Print("Запускаем SQL запрос по поиску псевдонима группы")
+
+	var groupID int
+	err := s.db.QueryRowContext(ctx, "SELECT group_id FROM group_aliases WHERE id = $1", id).Scan(&groupID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		s.errorLog.Println(err)
+	}
+	return groupID, err
+}
@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"context"
+	"effectiveMobile/models"
+	"fmt"
+	"strings"
+
+	"github.com/georgysavva/scany/v2/sqlscan"
+)
+
+// ftsTable — таблица FTS5 и способ сопоставления слов запроса. В SQLite
+// нет русского стеммера, поэтому русские слова ищутся по префиксу.
+type ftsTable struct {
+	name   string
+	prefix bool
+}
+
+var ftsTables = map[string][]ftsTable{
+	models.SearchLangRu:  {{name: "songs_fts", prefix: true}},
+	models.SearchLangEn:  {{name: "songs_fts_en"}},
+	models.SearchLangAny: {{name: "songs_fts", prefix: true}, {name: "songs_fts_en"}},
+}
+
+// Веса столбцов song_name, group_name и text для bm25, как A, B и C в Postgres
+const ftsWeights = "10.0, 5.0, 1.0"
+
+// SearchSongs ищет песни в индексах FTS5. Оценка bm25 тем лучше, чем
+// меньше, поэтому берётся с обратным знаком; при поиске на обоих языках
+// остаётся лучшая оценка и фрагмент из того же индекса.
+func (s *sqliteStorage) SearchSongs(ctx context.Context, search models.SearchQuery, page models.Pagination) (models.SongSearchPage, error) {
+	s.infoLog.Print("Запускаем SQL запрос по полнотекстовому поиску песен")
+	result := models.SongSearchPage{Items: []models.SongSearchHit{}}
+
+	tables, ok := ftsTables[search.Lang]
+	if !ok {
+		return result, ErrInvalidSearch
+	}
+
+	b := &whereBuilder{}
+	hits := make([]string, 0, len(tables))
+	for _, table := range tables {
+		match := ftsQuery(search.Text, table.prefix)
+		if match == "" {
+			// Как и websearch_to_tsquery, запрос без искомых слов ничего не находит
+			return result, nil
+		}
+
+		hits = append(hits, fmt.Sprintf(`SELECT rowid id,
+			-bm25(%[1]s, %[2]s) rank,
+			snippet(%[1]s, -1, '<b>', '</b>', '…', 20) snippet
+		FROM %[1]s WHERE %[1]s MATCH %[3]s`, table.name, ftsWeights, b.arg(match)))
+	}
+	with := "WITH hits AS MATERIALIZED (" + strings.Join(hits, " UNION ALL ") + ")"
+
+	err := s.db.QueryRowContext(ctx, with+" SELECT COUNT(DISTINCT id) FROM hits", b.args...).Scan(&result.Total)
+	if err != nil {
+		s.errorLog.Println(err)
+		return result, err
+	}
+
+	// bm25 и snippet работают только в запросе к самой таблице FTS5, поэтому
+	// hits материализуется. При MAX остальные столбцы берутся из строки с максимумом.
+	query := fmt.Sprintf(`%s
+	SELECT s.id, song_name name, group_name, s.release_date, s.release_date_precision, link, MAX(h.rank) rank, h.snippet
+	FROM hits h
+	INNER JOIN songs s ON s.id = h.id
+	INNER JOIN groups g ON g.id = s.group_id
+	GROUP BY s.id
+	ORDER BY rank DESC, s.id
+	LIMIT %s OFFSET %s`, with, b.arg(page.Limit), b.arg((page.Page-1)*page.Limit))
+
+	err = sqlscan.Select(ctx, s.db, &result.Items, query, b.args...)
+	if err != nil {
+		s.errorLog.Println(err)
+	}
+
+	return result, err
+}
+
+// ftsQuery переводит запрос в синтаксисе websearch_to_tsquery в запрос FTS5:
+// слова и "фразы в кавычках" должны встречаться все, or между ними означает
+// любое из них, а -слово исключает песни с этим словом. Каждый терм
+// заключается в кавычки, поэтому спецсимволы FTS5 из запроса не действуют.
+func ftsQuery(text string, prefix bool) string {
+	var clauses [][]string
+	var excluded []string
+	orNext := false
+
+	for _, token := range splitWebSearch(text) {
+		if !token.quoted && strings.EqualFold(token.text, "or") {
+			orNext = len(clauses) > 0
+			continue
+		}
+
+		term := ftsTerm(token.text, prefix)
+		if term == "" {
+			continue
+		}
+
+		switch {
+		case token.negated:
+			excluded = append(excluded, term)
+		case orNext:
+			last := len(clauses) - 1
+			clauses[last] = append(clauses[last], term)
+		default:
+			clauses = append(clauses, []string{term})
+		}
+		orNext = false
+	}
+
+	if len(clauses) == 0 {
+		return ""
+	}
+
+	parts := make([]string, len(clauses))
+	for i, clause := range clauses {
+		parts[i] = "(" + strings.Join(clause, " OR ") + ")"
+	}
+	query := strings.Join(parts, " AND ")
+
+	for _, term := range excluded {
+		query = "(" + query + ") NOT " + term
+	}
+
+	return query
+}
+
+// ftsTerm превращает слово или фразу в строку FTS5 в кавычках
+func ftsTerm(text string, prefix bool) string {
+	phrase := strings.Join(words(text), " ")
+	if phrase == "" {
+		return ""
+	}
+
+	term := `"` + phrase + `"`
+	if prefix {
+		term += "*"
+	}
+	return term
+}
+
+type webSearchToken struct {
+	text    string
+	quoted  bool
+	negated bool
+}
+
+// splitWebSearch разбивает запрос на слова и фразы в кавычках
+func splitWebSearch(text string) []webSearchToken {
+	var tokens []webSearchToken
+	runes := []rune(text)
+
+	for i := 0; i < len(runes); {
+		if runes[i] == ' ' || runes[i] == '\t' || runes[i] == '\n' {
+			i++
+			continue
+		}
+
+		token := webSearchToken{}
+		if runes[i] == '-' {
+			token.negated = true
+			i++
+		}
+
+		start := i
+		if i < len(runes) && runes[i] == '"' {
+			token.quoted = true
+			start = i + 1
+			end := start
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			token.text = string(runes[start:end])
+			i = end + 1
+		} else {
+			for i < len(runes) && runes[i] != ' ' && runes[i] != '\t' && runes[i] != '\n' {
+				i++
+			}
+			token.text = string(runes[start:i])
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	return tokens
+}
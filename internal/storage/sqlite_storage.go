@@ -0,0 +1,428 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"effectiveMobile/models"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/georgysavva/scany/v2/sqlscan"
+)
+
+// sqliteStorage хранит песни во встроенной базе SQLite. Запросы повторяют
+// запросы Postgres, отличия — в хранении дат и полнотекстовом поиске (FTS5).
+type sqliteStorage struct {
+	db       sqliteDB
+	conn     *sql.DB // nil внутри транзакции
+	infoLog  *log.Logger
+	errorLog *log.Logger
+}
+
+// sqliteDB — общее у *sql.DB и *sql.Tx, как DB для Postgres
+type sqliteDB interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func NewSQLiteSongStorage(db *sql.DB, infoLog, errorLog *log.Logger) SongStorage {
+	return &sqliteStorage{
+		db:       db,
+		conn:     db,
+		infoLog:  infoLog,
+		errorLog: errorLog,
+	}
+}
+
+func (s *sqliteStorage) GetAllSongs(ctx context.Context, filter models.SongFilter, page models.Pagination) (models.SongPage, error) {
+	s.infoLog.Print("Запускаем SQL запрос по получению всех песен")
+	result := models.SongPage{Items: []models.Song{}}
+
+	order, err := resolveSongOrder(page.Sort)
+	if err != nil {
+		return result, err
+	}
+
+	b := buildSongWhere(filter, sqliteILike)
+
+	countQuery := `SELECT COUNT(*)
+	FROM songs s
+	INNER JOIN groups g ON g.id = s.group_id
+	` + b.sql()
+
+	err = s.db.QueryRowContext(ctx, countQuery, b.args...).Scan(&result.Total)
+	if err != nil {
+		s.errorLog.Println(err)
+		return result, err
+	}
+
+	offset := 0
+	if page.After != "" {
+		cursor, err := decodeCursor(page.After)
+		if err != nil {
+			return result, err
+		}
+		cond, err := order.after(cursor, b)
+		if err != nil {
+			return result, err
+		}
+		b.add(cond)
+	} else {
+		offset = (page.Page - 1) * page.Limit
+	}
+
+	// Запрашиваем на одну песню больше, чтобы понять, есть ли следующая страница
+	query := fmt.Sprintf(`SELECT s.id, song_name name, group_name, release_date, release_date_precision, link,
+		album_id, disc_number, track_number
+	FROM songs s
+	INNER JOIN groups g ON g.id = s.group_id
+	%s
+	%s
+	LIMIT %s OFFSET %s`, b.sql(), order.orderBy(), b.arg(page.Limit+1), b.arg(offset))
+
+	err = sqlscan.Select(ctx, s.db, &result.Items, query, b.args...)
+	if err != nil {
+		s.errorLog.Println(err)
+		return result, err
+	}
+
+	if len(result.Items) > page.Limit {
+		result.Items = result.Items[:page.Limit]
+		last := result.Items[len(result.Items)-1]
+		result.NextCursor = order.cursor(last)
+	}
+
+	return result, nil
+}
+
+func (s *sqliteStorage) GetSongByID(ctx context.Context, id int) (models.Song, error) {
+	s.infoLog.Print("Запускаем SQL запрос по получению песни по ID")
+	query := `SELECT s.id, song_name name, group_name, release_date, release_date_precision, text, link,
+		album_id, disc_number, track_number, version
+	FROM songs s
+	INNER JOIN groups g ON g.id = s.group_id
+	WHERE s.id = $1`
+
+	var song models.Song
+	err := sqlscan.Get(ctx, s.db, &song, query, id)
+	if sqlscan.NotFound(err) {
+		return song, ErrNotFound
+	}
+	if err != nil {
+		s.errorLog.Println(err)
+	}
+
+	return song, err
+}
+
+// AddSong добавляет песню; песня с тем же названием у той же группы
+// возвращается как *ConflictError, как в реализации на Postgres
+func (s *sqliteStorage) AddSong(ctx context.Context, song models.Song) (int, error) {
+	s.infoLog.Print("Запускаем SQL запрос по добавлению песни")
+	query := `INSERT INTO songs (group_id, song_name, search_key, name_key, release_date, release_date_precision, text, link, needs_enrichment)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	ON CONFLICT (group_id, name_key) DO NOTHING
+	RETURNING id`
+
+	var id int
+	err := s.db.QueryRowContext(ctx, query,
+		song.Group, song.Name, searchKey(song.Name), nameKey(song.Name), dateString(song.ReleaseDate),
+		releasePrecision(song.ReleaseDate, song.ReleaseDatePrecision), song.Text, song.Link, song.NeedsEnrichment,
+	).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, s.songConflict(ctx, song)
+	}
+	if err != nil {
+		s.errorLog.Println(err)
+	}
+	return id, mapSQLiteError(err)
+}
+
+func (s *sqliteStorage) songConflict(ctx context.Context, song models.Song) error {
+	var id int
+	err := s.db.QueryRowContext(ctx, existingSongQuery, song.Group, nameKey(song.Name)).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrConflict
+	}
+	if err != nil {
+		s.errorLog.Println(err)
+		return err
+	}
+	return &ConflictError{ID: id}
+}
+
+func (s *sqliteStorage) FindSongByName(ctx context.Context, groupID int, name string) (int, error) {
+	s.infoLog.Print("Запускаем SQL запрос по поиску песни группы по названию")
+
+	var id int
+	err := s.db.QueryRowContext(ctx, existingSongQuery, groupID, nameKey(&name)).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		s.errorLog.Println(err)
+	}
+	return id, err
+}
+
+func (s *sqliteStorage) UpdateSong(ctx context.Context, id int, newSong models.Song, version int) error {
+	s.infoLog.Print("Запускаем SQL запрос по обновлению песни по ID")
+	query := `UPDATE songs SET song_name = $1, search_key = $2, name_key = $3, text = $4, link = $5, version = version + 1
+	WHERE id = $6 AND ` + versionMatches("$7")
+
+	result, err := s.db.ExecContext(ctx, query,
+		newSong.Name, searchKey(newSong.Name), nameKey(newSong.Name), newSong.Text, newSong.Link, id, version)
+	if err != nil {
+		s.errorLog.Println(err)
+		return mapSQLiteError(err)
+	}
+
+	return s.checkVersioned(ctx, result, id, version)
+}
+
+func (s *sqliteStorage) PatchSong(ctx context.Context, id int, changes models.SongChanges, version int) error {
+	s.infoLog.Print("Запускаем SQL запрос по частичному обновлению песни")
+
+	b := &whereBuilder{}
+	query := fmt.Sprintf("UPDATE songs SET %s WHERE id = %s AND %s",
+		songChangeSets(changes, b), b.arg(id), versionMatches(b.arg(version)))
+
+	result, err := s.db.ExecContext(ctx, query, b.args...)
+	if err != nil {
+		s.errorLog.Println(err)
+		return mapSQLiteError(err)
+	}
+
+	return s.checkVersioned(ctx, result, id, version)
+}
+
+func (s *sqliteStorage) DeleteSong(ctx context.Context, id int, version int) error {
+	s.infoLog.Print("Запускаем SQL запрос по удалению песни по ID")
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM songs WHERE id = $1 AND "+versionMatches("$2"), id, version)
+	if err != nil {
+		s.errorLog.Println(err)
+		return err
+	}
+
+	return s.checkVersioned(ctx, result, id, version)
+}
+
+// checkVersioned дополняет checkAffected: если песня есть, но запрос
+// её не изменил, значит не совпала версия
+func (s *sqliteStorage) checkVersioned(ctx context.Context, result sql.Result, id int, version int) error {
+	err := s.checkAffected(result)
+	if !errors.Is(err, ErrNotFound) || version == AnyVersion {
+		return err
+	}
+
+	var exists bool
+	err = s.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM songs WHERE id = $1)", id).Scan(&exists)
+	if err != nil {
+		s.errorLog.Println(err)
+		return err
+	}
+
+	return versionError(exists)
+}
+
+// checkAffected возвращает ErrNotFound, если запрос не изменил ни одной строки
+func (s *sqliteStorage) checkAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		s.errorLog.Println(err)
+		return err
+	}
+
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// AddGroup возвращает ID группы, создавая её при необходимости,
+// так же, как реализация на Postgres
+func (s *sqliteStorage) AddGroup(ctx context.Context, group models.Group) (int, error) {
+	s.infoLog.Print("Запускаем SQL запрос по добавлению группы")
+
+	// Группа с тем же названием без учёта регистра и пробелов уже есть
+	var groupID int
+	err := s.db.QueryRowContext(ctx, groupByNameKeyQuery, nameKey(group.Name), group.Name).Scan(&groupID)
+	if !errors.Is(err, sql.ErrNoRows) {
+		if err != nil {
+			s.errorLog.Println(err)
+		}
+		return groupID, err
+	}
+
+	query := `INSERT INTO groups (group_name, search_key, name_key) VALUES ($1, $2, $3)
+	ON CONFLICT (group_name) DO UPDATE SET group_name = excluded.group_name
+	RETURNING id`
+
+	err = s.db.QueryRowContext(ctx, query, group.Name, searchKey(group.Name), nameKey(group.Name)).Scan(&groupID)
+	if err != nil {
+		s.errorLog.Println(err)
+	}
+	return groupID, err
+}
+
+// WithTx выполняет fn в транзакции. Транзакции SQLite начинаются
+// с BEGIN IMMEDIATE (см. OpenSQLite), поэтому конкурирующая запись ждёт
+// busy_timeout, а не падает посреди транзакции. Вложенный вызов
+// использует точку сохранения.
+func (s *sqliteStorage) WithTx(ctx context.Context, fn func(tx SongStorage) error) error {
+	return s.inTx(ctx, func(tx *sqliteStorage) error { return fn(tx) })
+}
+
+// inTx выполняет fn в транзакции, как и WithTx, но передаёт хранилище
+// SQLite, чтобы методы из нескольких запросов могли выполнять их напрямую
+func (s *sqliteStorage) inTx(ctx context.Context, fn func(tx *sqliteStorage) error) error {
+	if s.conn == nil {
+		return s.withSavepoint(ctx, fn)
+	}
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		s.errorLog.Println(err)
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&sqliteStorage{db: tx, infoLog: s.infoLog, errorLog: s.errorLog}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.errorLog.Println(err)
+		return err
+	}
+	return nil
+}
+
+func (s *sqliteStorage) withSavepoint(ctx context.Context, fn func(tx *sqliteStorage) error) error {
+	if _, err := s.db.ExecContext(ctx, "SAVEPOINT unit_of_work"); err != nil {
+		s.errorLog.Println(err)
+		return err
+	}
+
+	if err := fn(s); err != nil {
+		// ROLLBACK TO не снимает точку сохранения, поэтому затем RELEASE
+		for _, query := range []string{"ROLLBACK TO unit_of_work", "RELEASE unit_of_work"} {
+			if _, rollbackErr := s.db.ExecContext(ctx, query); rollbackErr != nil {
+				s.errorLog.Println(rollbackErr)
+			}
+		}
+		return err
+	}
+
+	_, err := s.db.ExecContext(ctx, "RELEASE unit_of_work")
+	if err != nil {
+		s.errorLog.Println(err)
+	}
+	return err
+}
+
+// GetIncompleteSongs выбирает песни так же, как реализация на Postgres.
+// Время последней попытки хранится в секундах Unix.
+func (s *sqliteStorage) GetIncompleteSongs(ctx context.Context, limit, maxAttempts int, retryDelay time.Duration) ([]models.Song, error) {
+	s.infoLog.Print("Запускаем SQL запрос по получению неполных песен")
+	query := `SELECT s.id, song_name name, group_name
+	FROM songs s
+	INNER JOIN groups g ON g.id = s.group_id
+	WHERE (s.text IS NULL OR s.link IS NULL OR s.needs_enrichment)
+		AND s.enrich_attempts < $1
+		AND (s.enrich_last_attempt IS NULL
+			OR s.enrich_last_attempt < unixepoch() - $2 * (1 << s.enrich_attempts))
+	ORDER BY s.enrich_last_attempt NULLS FIRST, s.id
+	LIMIT $3`
+
+	var songs []models.Song
+	err := sqlscan.Select(ctx, s.db, &songs, query, maxAttempts, retryDelay.Seconds(), limit)
+	if err != nil {
+		s.errorLog.Println(err)
+	}
+
+	return songs, err
+}
+
+// SaveEnrichment, как и реализация на Postgres, сбрасывает счётчик попыток
+// только для песни, у которой есть и текст, и ссылка
+func (s *sqliteStorage) SaveEnrichment(ctx context.Context, id int, song models.Song) error {
+	s.infoLog.Print("Запускаем SQL запрос по сохранению обогащения песни")
+	query := `UPDATE songs SET
+		release_date = COALESCE($1, release_date),
+		release_date_precision = CASE WHEN $1 IS NULL THEN release_date_precision ELSE $5 END,
+		text = COALESCE(text, $2),
+		link = COALESCE(link, $3),
+		needs_enrichment = FALSE,
+		enrich_attempts = CASE WHEN COALESCE(text, $2) IS NOT NULL AND COALESCE(link, $3) IS NOT NULL
+			THEN 0 ELSE enrich_attempts + 1 END,
+		enrich_last_error = CASE WHEN COALESCE(text, $2) IS NOT NULL AND COALESCE(link, $3) IS NOT NULL
+			THEN NULL ELSE $6 END,
+		enrich_last_attempt = unixepoch(),
+		version = version + 1
+	WHERE id = $4`
+
+	result, err := s.db.ExecContext(ctx, query, dateString(song.ReleaseDate), song.Text, song.Link, id,
+		releasePrecision(song.ReleaseDate, song.ReleaseDatePrecision), enrichPartialReason)
+	if err != nil {
+		s.errorLog.Println(err)
+		return err
+	}
+
+	return s.checkAffected(result)
+}
+
+func (s *sqliteStorage) MarkEnrichmentFailed(ctx context.Context, id int, reason string) error {
+	s.infoLog.Print("Запускаем SQL запрос по отметке неудачного обогащения песни")
+	query := `UPDATE songs SET
+		enrich_attempts = enrich_attempts + 1,
+		enrich_last_error = $1,
+		enrich_last_attempt = unixepoch()
+	WHERE id = $2`
+
+	result, err := s.db.ExecContext(ctx, query, reason, id)
+	if err != nil {
+		s.errorLog.Println(err)
+		return err
+	}
+
+	return s.checkAffected(result)
+}
+
+// RefreshSearchKeys заполняет ключи строк, добавленных миграциями,
+// так же, как реализация на Postgres
+func (s *sqliteStorage) RefreshSearchKeys(ctx context.Context) (int, error) {
+	s.infoLog.Print("Запускаем SQL запрос по заполнению поисковых ключей")
+	updated := 0
+	var duplicates []int
+
+	for _, column := range keyColumns {
+		var rows []namedRow
+		if err := sqlscan.Select(ctx, s.db, &rows, column.selectQuery); err != nil {
+			s.errorLog.Println(err)
+			return updated, err
+		}
+
+		for _, row := range rows {
+			_, err := s.db.ExecContext(ctx, column.updateQuery, column.key(row.Name), row.ID)
+			if errors.Is(mapSQLiteError(err), ErrConflict) {
+				duplicates = append(duplicates, row.ID)
+				continue
+			}
+			if err != nil {
+				s.errorLog.Println(err)
+				return updated, err
+			}
+			updated++
+		}
+	}
+
+	if len(duplicates) > 0 {
+		return updated, &DuplicateNamesError{IDs: duplicates}
+	}
+	return updated, nil
+}
@@ -0,0 +1,82 @@
+package storage_test
+
+import (
+	"context"
+	"effectiveMobile/internal/migrations"
+	"effectiveMobile/internal/storage"
+	"effectiveMobile/internal/storage/storagetest"
+	"errors"
+	"io"
+	"log"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSQLiteSongStorage(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+
+	list, err := migrations.SQLite()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storagetest.RunSongStorageContract(t, func(t *testing.T) storage.SongStorage {
+		db, err := storage.OpenSQLite(filepath.Join(t.TempDir(), "music.db"))
+		if err != nil {
+			t.Fatalf("открытие SQLite: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		if _, err := migrations.NewSQLiteMigrator(db, list, logger).Up(context.Background()); err != nil {
+			t.Fatalf("миграции: %v", err)
+		}
+		// Начальные песни из миграций мешают проверкам выборки
+		if _, err := db.Exec("DELETE FROM songs; DELETE FROM groups"); err != nil {
+			t.Fatal(err)
+		}
+
+		return storage.NewSQLiteSongStorage(db, logger, logger)
+	})
+}
+
+func TestSQLiteRefreshSearchKeys(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+
+	list, err := migrations.SQLite()
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := storage.OpenSQLite(filepath.Join(t.TempDir(), "music.db"))
+	if err != nil {
+		t.Fatalf("открытие SQLite: %v", err)
+	}
+	defer db.Close()
+	if _, err := migrations.NewSQLiteMigrator(db, list, logger).Up(ctx); err != nil {
+		t.Fatalf("миграции: %v", err)
+	}
+	s := storage.NewSQLiteSongStorage(db, logger, logger)
+
+	// Песни, добавленные в обход приложения, приходят без ключей
+	_, err = db.Exec(`INSERT INTO groups (id, group_name) VALUES (100, 'Muse');
+	INSERT INTO songs (id, group_id, song_name) VALUES (100, 100, 'Uprising'), (101, 100, 'UPRISING '), (102, 100, 'Starlight')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := s.RefreshSearchKeys(ctx)
+	var duplicates *storage.DuplicateNamesError
+	if !errors.As(err, &duplicates) || !slices.Equal(duplicates.IDs, []int{101}) {
+		t.Fatalf("RefreshSearchKeys: ошибка %v, ожидался дубликат 101", err)
+	}
+	// Ключи группы и трёх песен, ключи уникальности двух песен
+	if updated < 6 {
+		t.Errorf("обновлено ключей: %d", updated)
+	}
+
+	// Дубликат остаётся без ключа и сообщается снова при следующем запуске
+	if _, err := s.RefreshSearchKeys(ctx); !errors.As(err, &duplicates) || !slices.Equal(duplicates.IDs, []int{101}) {
+		t.Errorf("повторный RefreshSearchKeys: %v", err)
+	}
+}
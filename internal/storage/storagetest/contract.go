@@ -0,0 +1,962 @@
+// Package storagetest содержит общий набор проверок поведения SongStorage.
+// Каждая реализация хранилища подключает его в своих тестах:
+//
+//	storagetest.RunSongStorageContract(t, func(t *testing.T) storage.SongStorage {
+//		return storage.NewMemorySongStorage(infoLog, errorLog)
+//	})
+package storagetest
+
+import (
+	"context"
+	"effectiveMobile/internal/storage"
+	"effectiveMobile/models"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// NewStorage создаёт пустое хранилище для одной проверки
+type NewStorage func(t *testing.T) storage.SongStorage
+
+// RunSongStorageContract проверяет, что хранилище ведёт себя так же,
+// как эталонная реализация на Postgres
+func RunSongStorageContract(t *testing.T, newStorage NewStorage) {
+	t.Run("AddGroupDeduplicates", func(t *testing.T) { testAddGroupDeduplicates(t, newStorage(t)) })
+	t.Run("AddAndGetSong", func(t *testing.T) { testAddAndGetSong(t, newStorage(t)) })
+	t.Run("NotFound", func(t *testing.T) { testNotFound(t, newStorage(t)) })
+	t.Run("SongNameConflict", func(t *testing.T) { testSongNameConflict(t, newStorage(t)) })
+	t.Run("UpdateAndDelete", func(t *testing.T) { testUpdateAndDelete(t, newStorage(t)) })
+	t.Run("PatchSong", func(t *testing.T) { testPatchSong(t, newStorage(t)) })
+	t.Run("Versions", func(t *testing.T) { testVersions(t, newStorage(t)) })
+	t.Run("Filters", func(t *testing.T) { testFilters(t, newStorage(t)) })
+	t.Run("SortAndCursor", func(t *testing.T) { testSortAndCursor(t, newStorage(t)) })
+	t.Run("OffsetPagination", func(t *testing.T) { testOffsetPagination(t, newStorage(t)) })
+	t.Run("Enrichment", func(t *testing.T) { testEnrichment(t, newStorage(t)) })
+	t.Run("UnitOfWork", func(t *testing.T) { testUnitOfWork(t, newStorage(t)) })
+	t.Run("Groups", func(t *testing.T) { testGroups(t, newStorage(t)) })
+	t.Run("DeleteGroup", func(t *testing.T) { testDeleteGroup(t, newStorage(t)) })
+	t.Run("GroupAliases", func(t *testing.T) { testGroupAliases(t, newStorage(t)) })
+	t.Run("Albums", func(t *testing.T) { testAlbums(t, newStorage(t)) })
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
+
+func mustAddGroup(t *testing.T, s storage.SongStorage, name string) int {
+	t.Helper()
+
+	id, err := s.AddGroup(context.Background(), models.Group{Name: &name})
+	if err != nil {
+		t.Fatalf("AddGroup(%q): %v", name, err)
+	}
+	return id
+}
+
+// mustCreateGroup создаёт группу, даже если есть группа с тем же
+// названием без учёта регистра и пробелов
+func mustCreateGroup(t *testing.T, s storage.SongStorage, name string) int {
+	t.Helper()
+
+	id, err := s.CreateGroup(context.Background(), models.Group{Name: &name})
+	if err != nil {
+		t.Fatalf("CreateGroup(%q): %v", name, err)
+	}
+	return id
+}
+
+func mustAddSong(t *testing.T, s storage.SongStorage, song models.Song) int {
+	t.Helper()
+
+	id, err := s.AddSong(context.Background(), song)
+	if err != nil {
+		t.Fatalf("AddSong(%q): %v", *song.Name, err)
+	}
+	return id
+}
+
+// mustFindSong возвращает ID песни по точному названию
+func mustFindSong(t *testing.T, s storage.SongStorage, name string) int {
+	t.Helper()
+
+	page, err := s.GetAllSongs(context.Background(),
+		models.SongFilter{Name: name, Match: models.MatchExact},
+		models.Pagination{Page: 1, Limit: 10})
+	if err != nil {
+		t.Fatalf("GetAllSongs(%q): %v", name, err)
+	}
+	if len(page.Items) != 1 || page.Items[0].ID == nil {
+		t.Fatalf("GetAllSongs(%q): найдено %d песен, ожидалась одна", name, len(page.Items))
+	}
+	return *page.Items[0].ID
+}
+
+func songNames(songs []models.Song) []string {
+	names := make([]string, len(songs))
+	for i, song := range songs {
+		names[i] = *song.Name
+	}
+	return names
+}
+
+func equalNames(got []string, want ...string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func testAddGroupDeduplicates(t *testing.T, s storage.SongStorage) {
+	first := mustAddGroup(t, s, "Muse")
+	second := mustAddGroup(t, s, "Muse")
+	other := mustAddGroup(t, s, "Placebo")
+
+	if first != second {
+		t.Errorf("повторное добавление группы вернуло новый ID: %d и %d", first, second)
+	}
+	if first == other {
+		t.Errorf("разные группы получили одинаковый ID %d", first)
+	}
+
+	// Параллельное добавление новой группы не должно падать на UNIQUE
+	ids := make(chan int, 8)
+	errs := make(chan error, 8)
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id, err := s.AddGroup(context.Background(), models.Group{Name: ptr("Radiohead")})
+			if err != nil {
+				errs <- err
+				return
+			}
+			ids <- id
+		}()
+	}
+	wg.Wait()
+	close(ids)
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("параллельный AddGroup: %v", err)
+	}
+	seen := map[int]bool{}
+	for id := range ids {
+		seen[id] = true
+	}
+	if len(seen) > 1 {
+		t.Errorf("параллельный AddGroup вернул разные ID: %v", seen)
+	}
+}
+
+func testAddAndGetSong(t *testing.T, s storage.SongStorage) {
+	groupID := mustAddGroup(t, s, "Muse")
+	releaseDate := models.NewDate(2006, time.July, 16)
+	id := mustAddSong(t, s, models.Song{
+		Group:       &groupID,
+		Name:        ptr("Supermassive Black Hole"),
+		ReleaseDate: &releaseDate,
+		Text:        ptr("Ooh baby, don't you know I suffer?"),
+		Link:        ptr("https://www.youtube.com/watch?v=Xsp3_a-PMTw"),
+	})
+
+	if found := mustFindSong(t, s, "Supermassive Black Hole"); found != id {
+		t.Errorf("AddSong вернул ID %d, песня найдена с ID %d", id, found)
+	}
+	song, err := s.GetSongByID(context.Background(), id)
+	if err != nil {
+		t.Fatalf("GetSongByID(%d): %v", id, err)
+	}
+
+	if song.Group_name == nil || *song.Group_name != "Muse" {
+		t.Errorf("группа песни = %v, ожидалась Muse", song.Group_name)
+	}
+	if song.Text == nil || *song.Text != "Ooh baby, don't you know I suffer?" {
+		t.Errorf("текст песни = %v", song.Text)
+	}
+	if song.ReleaseDate == nil || *song.ReleaseDate != releaseDate {
+		t.Errorf("дата релиза = %v, ожидалась %v", song.ReleaseDate, releaseDate)
+	}
+
+	// Неизвестная дата релиза не заменяется сегодняшней
+	undated := mustAddSong(t, s, models.Song{Group: &groupID, Name: ptr("Uprising")})
+	if song, err := s.GetSongByID(context.Background(), undated); err != nil || song.ReleaseDate != nil {
+		t.Errorf("песня без даты релиза: дата %v, %v", song.ReleaseDate, err)
+	}
+}
+
+func testNotFound(t *testing.T, s storage.SongStorage) {
+	ctx := context.Background()
+	const missingID = 1_000_000
+
+	if _, err := s.GetSongByID(ctx, missingID); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("GetSongByID: ошибка %v, ожидалась ErrNotFound", err)
+	}
+	if err := s.UpdateSong(ctx, missingID, models.Song{Name: ptr("x")}, 1); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("UpdateSong: ошибка %v, ожидалась ErrNotFound", err)
+	}
+	if err := s.DeleteSong(ctx, missingID, 1); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("DeleteSong: ошибка %v, ожидалась ErrNotFound", err)
+	}
+	if _, err := s.AddSong(ctx, models.Song{Group: ptr(missingID), Name: ptr("x")}); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("AddSong с несуществующей группой: ошибка %v, ожидалась ErrNotFound", err)
+	}
+}
+
+func testSongNameConflict(t *testing.T, s storage.SongStorage) {
+	ctx := context.Background()
+	dragons := mustAddGroup(t, s, "Imagine Dragons")
+	believer := mustAddSong(t, s, models.Song{Group: &dragons, Name: ptr("Believer")})
+
+	// Название сравнивается без учёта регистра и лишних пробелов
+	_, err := s.AddSong(ctx, models.Song{Group: &dragons, Name: ptr(" believer ")})
+	var conflict *storage.ConflictError
+	if !errors.As(err, &conflict) || !errors.Is(err, storage.ErrConflict) {
+		t.Fatalf("повторное добавление песни: ошибка %v, ожидалась ConflictError", err)
+	}
+	if conflict.ID != believer {
+		t.Errorf("ConflictError.ID = %d, ожидался %d", conflict.ID, believer)
+	}
+
+	// У другой группы может быть песня с тем же названием
+	cover := mustAddGroup(t, s, "Cover Band")
+	coverID := mustAddSong(t, s, models.Song{Group: &cover, Name: ptr("Believer")})
+
+	// Переименование и перенос в группу, где название уже занято
+	thunder := mustAddSong(t, s, models.Song{Group: &dragons, Name: ptr("Thunder")})
+	err = s.UpdateSong(ctx, thunder, models.Song{Name: ptr("BELIEVER")}, storage.AnyVersion)
+	if !errors.Is(err, storage.ErrConflict) {
+		t.Errorf("UpdateSong в занятое название: ошибка %v, ожидалась ErrConflict", err)
+	}
+	err = s.PatchSong(ctx, coverID, models.SongChanges{GroupID: models.Set(dragons)}, storage.AnyVersion)
+	if !errors.Is(err, storage.ErrConflict) {
+		t.Errorf("PatchSong в группу с тем же названием: ошибка %v, ожидалась ErrConflict", err)
+	}
+}
+
+func testUpdateAndDelete(t *testing.T, s storage.SongStorage) {
+	ctx := context.Background()
+	groupID := mustAddGroup(t, s, "Linkin Park")
+	mustAddSong(t, s, models.Song{Group: &groupID, Name: ptr("In the end")})
+	id := mustFindSong(t, s, "In the end")
+
+	err := s.UpdateSong(ctx, id, models.Song{Name: ptr("In The End"), Text: ptr("It starts with one thing")}, storage.AnyVersion)
+	if err != nil {
+		t.Fatalf("UpdateSong: %v", err)
+	}
+
+	song, err := s.GetSongByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetSongByID: %v", err)
+	}
+	if *song.Name != "In The End" || song.Text == nil || *song.Text != "It starts with one thing" {
+		t.Errorf("песня после обновления: %q, %v", *song.Name, song.Text)
+	}
+
+	if err := s.DeleteSong(ctx, id, storage.AnyVersion); err != nil {
+		t.Fatalf("DeleteSong: %v", err)
+	}
+	if _, err := s.GetSongByID(ctx, id); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("песня найдена после удаления: %v", err)
+	}
+}
+
+func testPatchSong(t *testing.T, s storage.SongStorage) {
+	ctx := context.Background()
+	muse := mustAddGroup(t, s, "Muse")
+	queen := mustAddGroup(t, s, "Queen")
+	id := mustAddSong(t, s, models.Song{Group: &muse, Name: ptr("Supermassive Black Hole"),
+		ReleaseDate: ptr(models.NewDate(2006, 6, 19)),
+		Text:        ptr("Ooh baby"), Link: ptr("https://example.com/smbh")})
+
+	// Меняем только группу и дату, а текст очищаем явным null
+	err := s.PatchSong(ctx, id, models.SongChanges{
+		GroupID:     models.Set(queen),
+		ReleaseDate: models.Set(models.NewDate(2006, 5, 9)),
+		Text:        models.PatchField[string]{Set: true},
+	}, storage.AnyVersion)
+	if err != nil {
+		t.Fatalf("PatchSong: %v", err)
+	}
+
+	song, err := s.GetSongByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetSongByID: %v", err)
+	}
+	if *song.Name != "Supermassive Black Hole" || *song.Group_name != "Queen" {
+		t.Errorf("песня после патча: %q, %q", *song.Name, *song.Group_name)
+	}
+	if song.ReleaseDate == nil || *song.ReleaseDate != models.NewDate(2006, 5, 9) {
+		t.Errorf("дата после патча: %v", song.ReleaseDate)
+	}
+	if song.Text != nil {
+		t.Errorf("текст не очищен: %q", *song.Text)
+	}
+	if song.Link == nil || *song.Link != "https://example.com/smbh" {
+		t.Errorf("ссылка изменилась: %v", song.Link)
+	}
+
+	err = s.PatchSong(ctx, id+100, models.SongChanges{Name: models.Set("Uprising")}, storage.AnyVersion)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("патч несуществующей песни: %v", err)
+	}
+}
+
+func testVersions(t *testing.T, s storage.SongStorage) {
+	ctx := context.Background()
+	groupID := mustAddGroup(t, s, "Muse")
+	id := mustAddSong(t, s, models.Song{Group: &groupID, Name: ptr("Uprising")})
+
+	song, err := s.GetSongByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetSongByID: %v", err)
+	}
+	if song.Version != 1 {
+		t.Fatalf("версия новой песни %d, ожидалась 1", song.Version)
+	}
+
+	if err := s.PatchSong(ctx, id, models.SongChanges{Text: models.Set("Paranoia")}, 1); err != nil {
+		t.Fatalf("PatchSong: %v", err)
+	}
+	if err := s.UpdateSong(ctx, id, models.Song{Name: ptr("Uprising")}, 2); err != nil {
+		t.Fatalf("UpdateSong: %v", err)
+	}
+
+	// Второй редактор прочитал песню до изменений
+	err = s.PatchSong(ctx, id, models.SongChanges{Link: models.Set("https://example.com")}, 1)
+	if !errors.Is(err, storage.ErrVersionMismatch) {
+		t.Errorf("PatchSong со старой версией: %v", err)
+	}
+	if err := s.UpdateSong(ctx, id, models.Song{Name: ptr("Uprising")}, 2); !errors.Is(err, storage.ErrVersionMismatch) {
+		t.Errorf("UpdateSong со старой версией: %v", err)
+	}
+	if err := s.DeleteSong(ctx, id, 2); !errors.Is(err, storage.ErrVersionMismatch) {
+		t.Errorf("DeleteSong со старой версией: %v", err)
+	}
+
+	song, err = s.GetSongByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetSongByID: %v", err)
+	}
+	if song.Version != 3 || song.Link != nil {
+		t.Errorf("песня после отклонённых изменений: версия %d, ссылка %v", song.Version, song.Link)
+	}
+
+	if err := s.DeleteSong(ctx, id, 3); err != nil {
+		t.Fatalf("DeleteSong: %v", err)
+	}
+}
+
+// addLibrary добавляет небольшую библиотеку для проверок выборки
+func addLibrary(t *testing.T, s storage.SongStorage) {
+	kino := mustAddGroup(t, s, "Кино")
+	muse := mustAddGroup(t, s, "Muse")
+
+	mustAddSong(t, s, models.Song{Group: &kino, Name: ptr("Группа крови"),
+		ReleaseDate: ptr(models.NewDate(1988, 1, 1)),
+		Text:        ptr("Тёплое место, но улицы ждут"), Link: ptr("https://example.com/1")})
+	mustAddSong(t, s, models.Song{Group: &kino, Name: ptr("Кукушка"),
+		ReleaseDate: ptr(models.NewDate(1990, 1, 1)),
+		Text:        ptr("Песен ещё ненаписанных сколько")})
+	mustAddSong(t, s, models.Song{Group: &muse, Name: ptr("Uprising"),
+		ReleaseDate: ptr(models.NewDate(2009, 9, 7)),
+		Text:        ptr("Paranoia is in bloom"), Link: ptr("https://example.com/3")})
+	mustAddSong(t, s, models.Song{Group: &muse, Name: ptr("Hysteria"),
+		ReleaseDate: ptr(models.NewDate(2003, 12, 1))})
+}
+
+func testFilters(t *testing.T, s storage.SongStorage) {
+	addLibrary(t, s)
+
+	tests := []struct {
+		name   string
+		filter models.SongFilter
+		want   []string
+	}{
+		{"транслитерация группы", models.SongFilter{Group: "kino"}, []string{"Группа крови", "Кукушка"}},
+		{"префикс названия", models.SongFilter{Name: "kuk", Match: models.MatchPrefix}, []string{"Кукушка"}},
+		{"точное название", models.SongFilter{Name: "hysteria", Match: models.MatchExact}, []string{"Hysteria"}},
+		{"текст", models.SongFilter{Text: "paranoia"}, []string{"Uprising"}},
+		{"диапазон дат", models.SongFilter{
+			ReleasedFrom: ptr(time.Date(1989, 1, 1, 0, 0, 0, 0, time.UTC)),
+			ReleasedTo:   ptr(time.Date(2005, 1, 1, 0, 0, 0, 0, time.UTC)),
+		}, []string{"Кукушка", "Hysteria"}},
+		{"есть ссылка", models.SongFilter{HasLink: ptr(true)}, []string{"Группа крови", "Uprising"}},
+		{"нет ссылки", models.SongFilter{HasLink: ptr(false)}, []string{"Кукушка", "Hysteria"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.filter.Match == "" {
+				tt.filter.Match = models.MatchContains
+			}
+			page, err := s.GetAllSongs(context.Background(), tt.filter, models.Pagination{Page: 1, Limit: 10})
+			if err != nil {
+				t.Fatalf("GetAllSongs: %v", err)
+			}
+			if got := songNames(page.Items); !equalNames(got, tt.want...) || page.Total != len(tt.want) {
+				t.Errorf("найдено %v (всего %d), ожидалось %v", got, page.Total, tt.want)
+			}
+		})
+	}
+}
+
+func testSortAndCursor(t *testing.T, s storage.SongStorage) {
+	addLibrary(t, s)
+	ctx := context.Background()
+	sort := []models.SortField{{Field: models.SortByGroup}, {Field: models.SortByReleaseDate, Desc: true}}
+	filter := models.SongFilter{Match: models.MatchContains}
+
+	var got []string
+	page := models.Pagination{Page: 1, Limit: 3, Sort: sort}
+	for {
+		result, err := s.GetAllSongs(ctx, filter, page)
+		if err != nil {
+			t.Fatalf("GetAllSongs: %v", err)
+		}
+		got = append(got, songNames(result.Items)...)
+
+		if result.NextCursor == "" {
+			break
+		}
+		page = models.Pagination{Limit: 3, Sort: sort, After: result.NextCursor}
+	}
+
+	if !equalNames(got, "Uprising", "Hysteria", "Кукушка", "Группа крови") {
+		t.Errorf("порядок по курсору: %v", got)
+	}
+
+	// Курсор другого порядка сортировки не принимается
+	first, _ := s.GetAllSongs(ctx, filter, models.Pagination{Page: 1, Limit: 1, Sort: sort})
+	_, err := s.GetAllSongs(ctx, filter, models.Pagination{Limit: 1, After: first.NextCursor})
+	if !errors.Is(err, storage.ErrInvalidCursor) {
+		t.Errorf("курсор другой сортировки: ошибка %v, ожидалась ErrInvalidCursor", err)
+	}
+}
+
+func testOffsetPagination(t *testing.T, s storage.SongStorage) {
+	addLibrary(t, s)
+
+	page, err := s.GetAllSongs(context.Background(),
+		models.SongFilter{Match: models.MatchContains},
+		models.Pagination{Page: 2, Limit: 3})
+	if err != nil {
+		t.Fatalf("GetAllSongs: %v", err)
+	}
+
+	if page.Total != 4 || !equalNames(songNames(page.Items), "Hysteria") {
+		t.Errorf("вторая страница: %v, всего %d", songNames(page.Items), page.Total)
+	}
+}
+
+func testEnrichment(t *testing.T, s storage.SongStorage) {
+	ctx := context.Background()
+	groupID := mustAddGroup(t, s, "Muse")
+	mustAddSong(t, s, models.Song{Group: &groupID, Name: ptr("Starlight")})
+	mustAddSong(t, s, models.Song{Group: &groupID, Name: ptr("Madness"),
+		Text: ptr("I, I can't get these memories"), Link: ptr("https://example.com")})
+
+	songs, err := s.GetIncompleteSongs(ctx, 10, 3, time.Hour)
+	if err != nil {
+		t.Fatalf("GetIncompleteSongs: %v", err)
+	}
+	if !equalNames(songNames(songs), "Starlight") {
+		t.Fatalf("неполные песни: %v", songNames(songs))
+	}
+	id := *songs[0].ID
+
+	// После неудачной попытки песня откладывается на retryDelay
+	if err := s.MarkEnrichmentFailed(ctx, id, "timeout"); err != nil {
+		t.Fatalf("MarkEnrichmentFailed: %v", err)
+	}
+	if songs, _ := s.GetIncompleteSongs(ctx, 10, 3, time.Hour); len(songs) != 0 {
+		t.Errorf("песня после неудачи не отложена: %v", songNames(songs))
+	}
+
+	err = s.SaveEnrichment(ctx, id, models.Song{Text: ptr("Far away"), Link: ptr("https://example.com/starlight")})
+	if err != nil {
+		t.Fatalf("SaveEnrichment: %v", err)
+	}
+	song, err := s.GetSongByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetSongByID: %v", err)
+	}
+	if song.Text == nil || *song.Text != "Far away" {
+		t.Errorf("текст после обогащения: %v", song.Text)
+	}
+
+	// Неполные данные считаются неудачной попыткой: песня снова выбирается
+	// только после паузы и не больше maxAttempts раз
+	hysteria := mustAddSong(t, s, models.Song{Group: &groupID, Name: ptr("Hysteria")})
+	released := models.NewDate(2003, time.December, 1)
+	err = s.SaveEnrichment(ctx, hysteria, models.Song{ReleaseDate: &released, ReleaseDatePrecision: models.PrecisionDay})
+	if err != nil {
+		t.Fatalf("SaveEnrichment только с датой: %v", err)
+	}
+	if song, err := s.GetSongByID(ctx, hysteria); err != nil || song.ReleaseDate == nil || !song.ReleaseDate.Equal(released.Time) {
+		t.Errorf("дата после обогащения: %+v, %v", song.ReleaseDate, err)
+	}
+	if songs, _ := s.GetIncompleteSongs(ctx, 10, 3, time.Hour); len(songs) != 0 {
+		t.Errorf("песня с неполными данными не отложена: %v", songNames(songs))
+	}
+	if songs, _ := s.GetIncompleteSongs(ctx, 10, 1, -time.Hour); len(songs) != 0 {
+		t.Errorf("попытка с неполными данными не учтена: %v", songNames(songs))
+	}
+	if songs, _ := s.GetIncompleteSongs(ctx, 10, 2, -time.Hour); !equalNames(songNames(songs), "Hysteria") {
+		t.Errorf("песня с неполными данными не выбрана повторно: %v", songNames(songs))
+	}
+}
+
+func testUnitOfWork(t *testing.T, s storage.SongStorage) {
+	ctx := context.Background()
+	errAbort := errors.New("откат")
+
+	// Ошибка внутри транзакции откатывает и группу, и песню
+	err := s.WithTx(ctx, func(tx storage.SongStorage) error {
+		groupID := mustAddGroup(t, tx, "Muse")
+		mustAddSong(t, tx, models.Song{Group: &groupID, Name: ptr("Uprising")})
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("WithTx: ошибка %v, ожидалась %v", err, errAbort)
+	}
+
+	page, err := s.GetAllSongs(ctx, models.SongFilter{}, models.Pagination{Page: 1, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 0 {
+		t.Errorf("после отката осталось песен: %d", page.Total)
+	}
+
+	// Ошибка хранилища внутри транзакции возвращается из WithTx
+	groupID := mustAddGroup(t, s, "Queen")
+	mustAddSong(t, s, models.Song{Group: &groupID, Name: ptr("Innuendo")})
+
+	err = s.WithTx(ctx, func(tx storage.SongStorage) error {
+		mustAddGroup(t, tx, "Placebo")
+		_, err := tx.AddSong(ctx, models.Song{Group: &groupID, Name: ptr("Innuendo")})
+		return err
+	})
+	if !errors.Is(err, storage.ErrConflict) {
+		t.Fatalf("WithTx: ошибка %v, ожидалась ErrConflict", err)
+	}
+
+	// Вложенная транзакция откатывается, не затрагивая внешнюю
+	err = s.WithTx(ctx, func(tx storage.SongStorage) error {
+		mustAddSong(t, tx, models.Song{Group: &groupID, Name: ptr("Bicycle Race")})
+
+		err := tx.WithTx(ctx, func(nested storage.SongStorage) error {
+			mustAddSong(t, nested, models.Song{Group: &groupID, Name: ptr("Mustapha")})
+			return errAbort
+		})
+		if !errors.Is(err, errAbort) {
+			t.Errorf("вложенный WithTx: ошибка %v, ожидалась %v", err, errAbort)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithTx: %v", err)
+	}
+
+	page, err = s.GetAllSongs(ctx, models.SongFilter{}, models.Pagination{Page: 1, Limit: 10, Sort: []models.SortField{{Field: models.SortBySong}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := songNames(page.Items); !equalNames(names, "Bicycle Race", "Innuendo") {
+		t.Errorf("после транзакций песни %v, ожидались [Bicycle Race Innuendo]", names)
+	}
+}
+
+func testGroups(t *testing.T, s storage.SongStorage) {
+	ctx := context.Background()
+	queen, err := s.CreateGroup(ctx, models.Group{
+		Name:       ptr("Queen"),
+		Country:    ptr("GB"),
+		FormedYear: ptr(1970),
+		Genres:     models.Genres{"rock", "glam rock"},
+		Bio:        ptr("Британская рок-группа"),
+	})
+	if err != nil {
+		t.Fatalf("CreateGroup: %v", err)
+	}
+	mustAddSong(t, s, models.Song{Group: &queen, Name: ptr("Bohemian Rhapsody")})
+	abba, err := s.CreateGroup(ctx, models.Group{Name: ptr("ABBA"), Genres: models.Genres{"pop"}})
+	if err != nil {
+		t.Fatalf("CreateGroup: %v", err)
+	}
+
+	group, err := s.GetGroupByID(ctx, queen)
+	if err != nil {
+		t.Fatalf("GetGroupByID: %v", err)
+	}
+	if *group.Name != "Queen" || group.Country == nil || *group.Country != "GB" ||
+		group.FormedYear == nil || *group.FormedYear != 1970 || !equalNames(group.Genres, "rock", "glam rock") ||
+		group.SongCount != 1 {
+		t.Errorf("группа после создания: %+v", group)
+	}
+
+	var conflict *storage.ConflictError
+	if _, err := s.CreateGroup(ctx, models.Group{Name: ptr("Queen")}); !errors.As(err, &conflict) || conflict.ID != queen {
+		t.Errorf("CreateGroup с занятым названием: ошибка %v, ожидалась ConflictError с ID %d", err, queen)
+	}
+	if err := s.UpdateGroup(ctx, abba, models.Group{Name: ptr("Queen")}); !errors.As(err, &conflict) || conflict.ID != queen {
+		t.Errorf("UpdateGroup в занятое название: ошибка %v, ожидалась ConflictError с ID %d", err, queen)
+	}
+	if err := s.UpdateGroup(ctx, 1000, models.Group{Name: ptr("Nobody")}); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("UpdateGroup несуществующей группы: ошибка %v, ожидалась ErrNotFound", err)
+	}
+
+	// Обновление заменяет описание целиком и меняет версии песен группы,
+	// в представлении которых есть её название
+	waterloo := mustAddSong(t, s, models.Song{Group: &abba, Name: ptr("Waterloo")})
+	before, err := s.GetSongByID(ctx, waterloo)
+	if err != nil {
+		t.Fatalf("GetSongByID: %v", err)
+	}
+	if err := s.UpdateGroup(ctx, abba, models.Group{Name: ptr("ABBA"), Country: ptr("SE")}); err != nil {
+		t.Fatalf("UpdateGroup: %v", err)
+	}
+	if song, err := s.GetSongByID(ctx, waterloo); err != nil || song.Version != before.Version+1 {
+		t.Errorf("версия песни после обновления группы: %d, %v, ожидалась %d", song.Version, err, before.Version+1)
+	}
+	group, err = s.GetGroupByID(ctx, abba)
+	if err != nil {
+		t.Fatalf("GetGroupByID: %v", err)
+	}
+	if group.Country == nil || *group.Country != "SE" || len(group.Genres) != 0 {
+		t.Errorf("группа после обновления: %+v", group)
+	}
+
+	page, err := s.GetAllGroups(ctx, models.GroupFilter{}, models.Pagination{Page: 1, Limit: 10})
+	if err != nil {
+		t.Fatalf("GetAllGroups: %v", err)
+	}
+	if page.Total != 2 || len(page.Items) != 2 || *page.Items[0].Name != "ABBA" {
+		t.Errorf("GetAllGroups: всего %d, %+v", page.Total, page.Items)
+	}
+
+	page, err = s.GetAllGroups(ctx, models.GroupFilter{Genre: "rock"}, models.Pagination{Page: 1, Limit: 10})
+	if err != nil {
+		t.Fatalf("GetAllGroups по жанру: %v", err)
+	}
+	if page.Total != 1 || len(page.Items) != 1 || page.Items[0].ID != queen {
+		t.Errorf("GetAllGroups по жанру: всего %d, %+v", page.Total, page.Items)
+	}
+
+	page, err = s.GetAllGroups(ctx, models.GroupFilter{Name: "ue"}, models.Pagination{Page: 1, Limit: 10})
+	if err != nil {
+		t.Fatalf("GetAllGroups по названию: %v", err)
+	}
+	if page.Total != 1 || len(page.Items) != 1 || page.Items[0].ID != queen {
+		t.Errorf("GetAllGroups по названию: всего %d, %+v", page.Total, page.Items)
+	}
+}
+
+func testDeleteGroup(t *testing.T, s storage.SongStorage) {
+	ctx := context.Background()
+	empty := mustAddGroup(t, s, "Empty")
+	beatles := mustAddGroup(t, s, "The Beatles")
+	song := mustAddSong(t, s, models.Song{Group: &beatles, Name: ptr("Yesterday")})
+
+	if err := s.DeleteGroup(ctx, empty, false); err != nil {
+		t.Fatalf("DeleteGroup без песен: %v", err)
+	}
+	if _, err := s.GetGroupByID(ctx, empty); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("группа найдена после удаления: %v", err)
+	}
+	if err := s.DeleteGroup(ctx, empty, false); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("повторное удаление группы: ошибка %v, ожидалась ErrNotFound", err)
+	}
+
+	if err := s.DeleteGroup(ctx, beatles, false); !errors.Is(err, storage.ErrGroupNotEmpty) {
+		t.Errorf("удаление группы с песнями: ошибка %v, ожидалась ErrGroupNotEmpty", err)
+	}
+	if _, err := s.GetSongByID(ctx, song); err != nil {
+		t.Errorf("песня пропала после неудачного удаления группы: %v", err)
+	}
+
+	if err := s.DeleteGroup(ctx, beatles, true); err != nil {
+		t.Fatalf("DeleteGroup с cascade: %v", err)
+	}
+	if _, err := s.GetSongByID(ctx, song); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("песня найдена после каскадного удаления группы: %v", err)
+	}
+}
+
+func testGroupAliases(t *testing.T, s storage.SongStorage) {
+	ctx := context.Background()
+	target := mustAddGroup(t, s, "Linkin Park")
+	lower := mustCreateGroup(t, s, "linkin park ")
+	upper := mustCreateGroup(t, s, "LINKIN PARK")
+	numb := mustAddSong(t, s, models.Song{Group: &target, Name: ptr("Numb")})
+
+	// AddGroup находит группу по названию без учёта регистра и пробелов,
+	// из дубликатов — с точно таким же названием, иначе первую
+	if id := mustAddGroup(t, s, "LINKIN PARK"); id != upper {
+		t.Errorf("AddGroup дубликата = %d, ожидался %d", id, upper)
+	}
+	if id := mustAddGroup(t, s, "Linkin  park"); id != target {
+		t.Errorf("AddGroup другого написания = %d, ожидался %d", id, target)
+	}
+
+	if id, err := s.FindSongByName(ctx, target, " NUMB "); err != nil || id != numb {
+		t.Errorf("FindSongByName = %d, %v, ожидался %d", id, err, numb)
+	}
+	if _, err := s.FindSongByName(ctx, lower, "Numb"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("FindSongByName в другой группе: ошибка %v, ожидалась ErrNotFound", err)
+	}
+
+	// Группу с песнями нельзя заменить псевдонимом
+	faint := mustAddSong(t, s, models.Song{Group: &upper, Name: ptr("Faint")})
+	if err := s.AliasGroup(ctx, upper, target); !errors.Is(err, storage.ErrGroupNotEmpty) {
+		t.Errorf("AliasGroup группы с песнями: ошибка %v, ожидалась ErrGroupNotEmpty", err)
+	}
+	if _, err := s.ResolveGroupAlias(ctx, upper); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("псевдоним остался после неудачного AliasGroup: %v", err)
+	}
+
+	if err := s.AliasGroup(ctx, lower, upper); err != nil {
+		t.Fatalf("AliasGroup: %v", err)
+	}
+	if _, err := s.GetGroupByID(ctx, lower); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("группа найдена после AliasGroup: %v", err)
+	}
+	if id, err := s.ResolveGroupAlias(ctx, lower); err != nil || id != upper {
+		t.Errorf("ResolveGroupAlias = %d, %v, ожидался %d", id, err, upper)
+	}
+	if _, err := s.ResolveGroupAlias(ctx, upper); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("ResolveGroupAlias существующей группы: ошибка %v, ожидалась ErrNotFound", err)
+	}
+
+	// Псевдонимы влитой группы переходят к новой цели
+	if err := s.DeleteSong(ctx, faint, storage.AnyVersion); err != nil {
+		t.Fatalf("DeleteSong: %v", err)
+	}
+	if err := s.AliasGroup(ctx, upper, target); err != nil {
+		t.Fatalf("AliasGroup: %v", err)
+	}
+	for _, alias := range []int{lower, upper} {
+		if id, err := s.ResolveGroupAlias(ctx, alias); err != nil || id != target {
+			t.Errorf("ResolveGroupAlias(%d) = %d, %v, ожидался %d", alias, id, err, target)
+		}
+	}
+	// Написание влитого дубликата не создаёт группу заново
+	if id := mustAddGroup(t, s, "LINKIN PARK"); id != target {
+		t.Errorf("AddGroup после объединения = %d, ожидался %d", id, target)
+	}
+
+	// Псевдонимы удаляются вместе с группой
+	if err := s.DeleteGroup(ctx, target, true); err != nil {
+		t.Fatalf("DeleteGroup: %v", err)
+	}
+	if _, err := s.ResolveGroupAlias(ctx, lower); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("псевдоним удалённой группы: ошибка %v, ожидалась ErrNotFound", err)
+	}
+}
+
+func albumTitles(albums []models.Album) []string {
+	titles := make([]string, len(albums))
+	for i, album := range albums {
+		titles[i] = *album.Title
+	}
+	return titles
+}
+
+func testAlbums(t *testing.T, s storage.SongStorage) {
+	ctx := context.Background()
+	muse := mustAddGroup(t, s, "Muse")
+	placebo := mustAddGroup(t, s, "Placebo")
+
+	if _, err := s.CreateAlbum(ctx, models.Album{Title: ptr("Nowhere"), Group: ptr(1000), Type: models.AlbumLP}); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("CreateAlbum с несуществующей группой: ошибка %v, ожидалась ErrNotFound", err)
+	}
+
+	released := models.NewDate(2006, time.July, 3)
+	blackHoles, err := s.CreateAlbum(ctx, models.Album{
+		Title:                ptr("Black Holes and Revelations"),
+		Group:                &muse,
+		ReleaseDate:          &released,
+		ReleaseDatePrecision: models.PrecisionDay,
+		Type:                 models.AlbumLP,
+	})
+	if err != nil {
+		t.Fatalf("CreateAlbum: %v", err)
+	}
+	drones := models.NewDate(2015, time.January, 1)
+	dronesID, err := s.CreateAlbum(ctx, models.Album{
+		Title: ptr("Drones"), Group: &muse, ReleaseDate: &drones, ReleaseDatePrecision: models.PrecisionYear, Type: models.AlbumLP,
+	})
+	if err != nil {
+		t.Fatalf("CreateAlbum: %v", err)
+	}
+	sampler, err := s.CreateAlbum(ctx, models.Album{Title: ptr("Sampler"), Type: models.AlbumCompilation})
+	if err != nil {
+		t.Fatalf("CreateAlbum без группы: %v", err)
+	}
+
+	album, err := s.GetAlbumByID(ctx, blackHoles)
+	if err != nil {
+		t.Fatalf("GetAlbumByID: %v", err)
+	}
+	if album.GroupName == nil || *album.GroupName != "Muse" || album.ReleaseDate == nil || !album.ReleaseDate.Equal(released.Time) || album.Type != models.AlbumLP {
+		t.Errorf("GetAlbumByID = %+v", album)
+	}
+
+	// Альбомы без даты идут в конце
+	page, err := s.GetAllAlbums(ctx, models.AlbumFilter{}, models.Pagination{Page: 1, Limit: 10})
+	if err != nil {
+		t.Fatalf("GetAllAlbums: %v", err)
+	}
+	if got := albumTitles(page.Items); page.Total != 3 || !equalNames(got, "Black Holes and Revelations", "Drones", "Sampler") {
+		t.Errorf("GetAllAlbums = %v (всего %d)", got, page.Total)
+	}
+	page, err = s.GetAllAlbums(ctx, models.AlbumFilter{Title: "HOLES", GroupID: &muse, Type: models.AlbumLP}, models.Pagination{Page: 1, Limit: 10})
+	if err != nil {
+		t.Fatalf("GetAllAlbums с фильтром: %v", err)
+	}
+	if got := albumTitles(page.Items); !equalNames(got, "Black Holes and Revelations") {
+		t.Errorf("GetAllAlbums с фильтром = %v", got)
+	}
+
+	// Песни альбома идут по дискам и номерам, песня без номера — в конце диска
+	tracks := []struct {
+		name        string
+		disc, track *int
+	}{
+		{"Knights of Cydonia", nil, ptr(11)},
+		{"Take a Bow", ptr(1), ptr(1)},
+		{"Bonus", ptr(2), ptr(1)},
+		{"Hidden", nil, nil},
+		{"Starlight", nil, ptr(2)},
+	}
+	for _, track := range tracks {
+		id := mustAddSong(t, s, models.Song{Group: &muse, Name: ptr(track.name)})
+		changes := models.SongChanges{AlbumID: models.Set(blackHoles)}
+		if track.disc != nil {
+			changes.DiscNumber = models.Set(*track.disc)
+		}
+		if track.track != nil {
+			changes.TrackNumber = models.Set(*track.track)
+		}
+		if err := s.PatchSong(ctx, id, changes, storage.AnyVersion); err != nil {
+			t.Fatalf("PatchSong(%q): %v", track.name, err)
+		}
+	}
+	mustAddSong(t, s, models.Song{Group: &muse, Name: ptr("Single")})
+
+	songs, err := s.GetAlbumTracks(ctx, blackHoles)
+	if err != nil {
+		t.Fatalf("GetAlbumTracks: %v", err)
+	}
+	if got := songNames(songs); !equalNames(got, "Take a Bow", "Starlight", "Knights of Cydonia", "Hidden", "Bonus") {
+		t.Errorf("GetAlbumTracks = %v", got)
+	}
+	if songs[0].AlbumID == nil || *songs[0].AlbumID != blackHoles || songs[0].TrackNumber == nil || *songs[0].TrackNumber != 1 {
+		t.Errorf("песня альбома = %+v", songs[0])
+	}
+	if album, _ := s.GetAlbumByID(ctx, blackHoles); album.TrackCount != 5 {
+		t.Errorf("TrackCount = %d, ожидалось 5", album.TrackCount)
+	}
+
+	if err := s.PatchSong(ctx, mustFindSong(t, s, "Single"), models.SongChanges{AlbumID: models.Set(1000)}, storage.AnyVersion); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("PatchSong с несуществующим альбомом: ошибка %v, ожидалась ErrNotFound", err)
+	}
+
+	// Место в альбоме занимает одна песня, диск без номера считается первым
+	single := mustFindSong(t, s, "Single")
+	taken := models.SongChanges{AlbumID: models.Set(blackHoles), TrackNumber: models.Set(1)}
+	if err := s.PatchSong(ctx, single, taken, storage.AnyVersion); !errors.Is(err, storage.ErrConflict) {
+		t.Errorf("PatchSong на занятое место в альбоме: ошибка %v, ожидалась ErrConflict", err)
+	}
+	taken.DiscNumber = models.Set(3)
+	if err := s.PatchSong(ctx, single, taken, storage.AnyVersion); err != nil {
+		t.Errorf("PatchSong на свободное место на другом диске: %v", err)
+	}
+	if err := s.PatchSong(ctx, single, models.SongChanges{AlbumID: models.Set(blackHoles)}, storage.AnyVersion); err != nil {
+		t.Errorf("PatchSong второй песни без номера трека: %v", err)
+	}
+	if err := s.PatchSong(ctx, single, models.SongChanges{AlbumID: models.PatchField[int]{Set: true}}, storage.AnyVersion); err != nil {
+		t.Fatalf("PatchSong без альбома: %v", err)
+	}
+
+	// Фильтры песен по альбому
+	filtered, err := s.GetAllSongs(ctx, models.SongFilter{Album: "black holes", Match: models.MatchPrefix}, models.Pagination{Page: 1, Limit: 10})
+	if err != nil {
+		t.Fatalf("GetAllSongs по названию альбома: %v", err)
+	}
+	if filtered.Total != 5 {
+		t.Errorf("GetAllSongs по названию альбома: найдено %d песен, ожидалось 5", filtered.Total)
+	}
+	filtered, err = s.GetAllSongs(ctx, models.SongFilter{AlbumID: &dronesID}, models.Pagination{Page: 1, Limit: 10})
+	if err != nil {
+		t.Fatalf("GetAllSongs по ID альбома: %v", err)
+	}
+	if filtered.Total != 0 {
+		t.Errorf("GetAllSongs по ID пустого альбома: найдено %d песен", filtered.Total)
+	}
+
+	// Замена альбома переносит его в другую группу и очищает незаданные поля
+	if err := s.UpdateAlbum(ctx, dronesID, models.Album{Title: ptr("Meds"), Group: &placebo, Type: models.AlbumEP}); err != nil {
+		t.Fatalf("UpdateAlbum: %v", err)
+	}
+	album, err = s.GetAlbumByID(ctx, dronesID)
+	if err != nil {
+		t.Fatalf("GetAlbumByID: %v", err)
+	}
+	if *album.Title != "Meds" || album.Group == nil || *album.Group != placebo || album.ReleaseDate != nil || album.Type != models.AlbumEP {
+		t.Errorf("альбом после UpdateAlbum = %+v", album)
+	}
+	if err := s.UpdateAlbum(ctx, 1000, models.Album{Title: ptr("Nowhere"), Type: models.AlbumLP}); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("UpdateAlbum несуществующего альбома: ошибка %v, ожидалась ErrNotFound", err)
+	}
+
+	// Песни удалённого альбома остаются без альбома и без номеров, их версии меняются
+	before, err := s.GetSongByID(ctx, mustFindSong(t, s, "Take a Bow"))
+	if err != nil {
+		t.Fatalf("GetSongByID: %v", err)
+	}
+	if err := s.DeleteAlbum(ctx, blackHoles); err != nil {
+		t.Fatalf("DeleteAlbum: %v", err)
+	}
+	if _, err := s.GetAlbumByID(ctx, blackHoles); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("альбом найден после удаления: %v", err)
+	}
+	song, err := s.GetSongByID(ctx, mustFindSong(t, s, "Take a Bow"))
+	if err != nil {
+		t.Fatalf("песня пропала вместе с альбомом: %v", err)
+	}
+	if song.AlbumID != nil || song.DiscNumber != nil || song.TrackNumber != nil {
+		t.Errorf("у песни остался удалённый альбом: %v, диск %v, трек %v", song.AlbumID, song.DiscNumber, song.TrackNumber)
+	}
+	if song.Version != before.Version+1 {
+		t.Errorf("версия песни после удаления альбома: %d, ожидалась %d", song.Version, before.Version+1)
+	}
+	if err := s.DeleteAlbum(ctx, blackHoles); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("повторное удаление альбома: ошибка %v, ожидалась ErrNotFound", err)
+	}
+
+	// Альбомы влитой группы переходят к новой, а удалённой — удаляются вместе с ней
+	if err := s.AliasGroup(ctx, placebo, muse); err != nil {
+		t.Fatalf("AliasGroup: %v", err)
+	}
+	if album, err := s.GetAlbumByID(ctx, dronesID); err != nil || album.Group == nil || *album.Group != muse {
+		t.Errorf("альбом влитой группы = %+v, %v", album, err)
+	}
+	if err := s.DeleteGroup(ctx, muse, true); err != nil {
+		t.Fatalf("DeleteGroup: %v", err)
+	}
+	if _, err := s.GetAlbumByID(ctx, dronesID); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("альбом удалённой группы: ошибка %v, ожидалась ErrNotFound", err)
+	}
+	if _, err := s.GetAlbumByID(ctx, sampler); err != nil {
+		t.Errorf("сборник без группы пропал: %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"strings"
+	"unicode"
+)
+
+// words разбивает строку на слова в нижнем регистре
+func words(value string) []string {
+	return strings.FieldsFunc(strings.ToLower(value), isNotWordRune)
+}
+
+func isNotWordRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// trigrams разбивает строку на триграммы так же, как pg_trgm: слова из букв
+// и цифр в нижнем регистре дополняются двумя пробелами слева и одним справа
+func trigrams(value string) map[string]struct{} {
+	set := make(map[string]struct{})
+
+	for _, word := range words(value) {
+		runes := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			set[string(runes[i:i+3])] = struct{}{}
+		}
+	}
+
+	return set
+}
+
+// similarity — доля общих триграмм, аналог similarity() из pg_trgm
+func similarity(a, b string) float64 {
+	left, right := trigrams(a), trigrams(b)
+	if len(left) == 0 || len(right) == 0 {
+		return 0
+	}
+
+	common := 0
+	for trigram := range left {
+		if _, ok := right[trigram]; ok {
+			common++
+		}
+	}
+
+	return float64(common) / float64(len(left)+len(right)-common)
+}
@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// UnitOfWork позволяет выполнить несколько операций хранилища атомарно.
+// WithTx передаёт в fn хранилище, все запросы которого идут в одной
+// транзакции: если fn вернула ошибку, изменения откатываются, иначе
+// фиксируются. Вложенный вызов WithTx создаёт точку сохранения.
+// Хранилище tx нельзя использовать после возврата из fn.
+type UnitOfWork interface {
+	WithTx(ctx context.Context, fn func(tx SongStorage) error) error
+}
+
+func (s *songStorage) WithTx(ctx context.Context, fn func(tx SongStorage) error) error {
+	return s.inTx(ctx, func(tx *songStorage) error { return fn(tx) })
+}
+
+// inTx выполняет fn в транзакции; методам хранилища из нескольких
+// запросов он даёт прямой доступ к запросам внутри неё
+func (s *songStorage) inTx(ctx context.Context, fn func(tx *songStorage) error) error {
+	// Begin у транзакции pgx создаёт точку сохранения
+	return pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		return fn(&songStorage{db: tx, infoLog: s.infoLog, errorLog: s.errorLog})
+	})
+}
@@ -0,0 +1,209 @@
+package usecase
+
+import (
+	"context"
+	"effectiveMobile/internal/storage"
+	"effectiveMobile/internal/validation"
+	"effectiveMobile/models"
+	"fmt"
+	"log"
+)
+
+const (
+	defaultAlbumsLimit = 20
+	maxAlbumsLimit     = 100
+)
+
+type AlbumUsecase interface {
+	GetAllAlbums(ctx context.Context, filter models.AlbumFilter, page models.Pagination) (models.AlbumPage, error)
+	GetAlbumByID(ctx context.Context, id int) (models.Album, error)
+	// GetAlbumTracks возвращает альбом и его песни в порядке треков
+	GetAlbumTracks(ctx context.Context, id int) (models.AlbumTracks, error)
+	// CreateAlbum добавляет альбом. Группа по названию создаётся, если её
+	// нет, в одной транзакции с альбомом.
+	CreateAlbum(ctx context.Context, input models.AlbumInput) (int, error)
+	UpdateAlbum(ctx context.Context, id int, input models.AlbumInput) error
+	// DeleteAlbum удаляет альбом, песни остаются в каталоге без альбома
+	DeleteAlbum(ctx context.Context, id int) error
+}
+
+type albumUsecase struct {
+	storage  storage.SongStorage
+	infoLog  *log.Logger
+	errorLog *log.Logger
+}
+
+func NewAlbumUsecase(s storage.SongStorage, infoLog, errorLog *log.Logger) AlbumUsecase {
+	return &albumUsecase{
+		storage:  s,
+		infoLog:  infoLog,
+		errorLog: errorLog,
+	}
+}
+
+func (uc *albumUsecase) GetAllAlbums(ctx context.Context, filter models.AlbumFilter, page models.Pagination) (models.AlbumPage, error) {
+	var v validation.Errors
+	page.Page, page.Limit = normalizePage(page.Page, page.Limit, defaultAlbumsLimit, maxAlbumsLimit, &v)
+	v.AlbumType("type", &filter.Type)
+	if err := v.Err(); err != nil {
+		return models.AlbumPage{}, err
+	}
+
+	// Альбомы объединённой группы перенесены в группу, в которую её влили
+	if filter.GroupID != nil {
+		groupID, err := resolveGroupID(ctx, uc.storage, *filter.GroupID)
+		if err != nil {
+			return models.AlbumPage{}, err
+		}
+		filter.GroupID = &groupID
+	}
+
+	return uc.storage.GetAllAlbums(ctx, filter, page)
+}
+
+func (uc *albumUsecase) GetAlbumByID(ctx context.Context, id int) (models.Album, error) {
+	return uc.storage.GetAlbumByID(ctx, id)
+}
+
+func (uc *albumUsecase) GetAlbumTracks(ctx context.Context, id int) (models.AlbumTracks, error) {
+	// У несуществующего альбома нет и пустого списка песен
+	album, err := uc.storage.GetAlbumByID(ctx, id)
+	if err != nil {
+		return models.AlbumTracks{}, err
+	}
+
+	tracks, err := uc.storage.GetAlbumTracks(ctx, id)
+	if err != nil {
+		return models.AlbumTracks{}, err
+	}
+
+	return models.AlbumTracks{Album: album, Tracks: tracks}, nil
+}
+
+func (uc *albumUsecase) CreateAlbum(ctx context.Context, input models.AlbumInput) (int, error) {
+	album, err := validateAlbum(&input)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int
+	err = uc.storage.WithTx(ctx, func(tx storage.SongStorage) error {
+		if err := albumGroup(ctx, tx, input, &album); err != nil {
+			return err
+		}
+
+		var err error
+		id, err = tx.CreateAlbum(ctx, album)
+		return err
+	})
+	return id, err
+}
+
+func (uc *albumUsecase) UpdateAlbum(ctx context.Context, id int, input models.AlbumInput) error {
+	album, err := validateAlbum(&input)
+	if err != nil {
+		return err
+	}
+
+	return uc.storage.WithTx(ctx, func(tx storage.SongStorage) error {
+		if err := albumGroup(ctx, tx, input, &album); err != nil {
+			return err
+		}
+		if album.Group != nil {
+			if err := checkTracksGroup(ctx, tx, id, *album.Group); err != nil {
+				return err
+			}
+		}
+		return tx.UpdateAlbum(ctx, id, album)
+	})
+}
+
+func (uc *albumUsecase) DeleteAlbum(ctx context.Context, id int) error {
+	return uc.storage.DeleteAlbum(ctx, id)
+}
+
+// albumGroup проверяет группу альбома, заданную ID (в том числе старым ID
+// объединённой группы), или создаёт группу, заданную названием
+func albumGroup(ctx context.Context, tx storage.SongStorage, input models.AlbumInput, album *models.Album) error {
+	var groupID int
+	var err error
+	switch {
+	case input.Group != nil:
+		groupID, err = existingGroupID(ctx, tx, "group", *input.Group)
+	case input.GroupName != nil:
+		groupID, err = tx.AddGroup(ctx, models.Group{Name: input.GroupName})
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	album.Group = &groupID
+	return nil
+}
+
+// checkTracksGroup проверяет, что все песни альбома принадлежат его новой
+// группе. Альбом без группы, например сборник, может содержать любые песни.
+func checkTracksGroup(ctx context.Context, tx storage.SongStorage, id int, groupID int) error {
+	group, err := tx.GetGroupByID(ctx, groupID)
+	if err != nil {
+		return err
+	}
+
+	tracks, err := tx.GetAlbumTracks(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// Представление песни содержит только название группы, а оно уникально
+	for _, track := range tracks {
+		if track.Group_name == nil || *track.Group_name != *group.Name {
+			return InvalidField("group", fmt.Sprintf("в альбоме есть песня %d другой группы", *track.ID))
+		}
+	}
+	return nil
+}
+
+// validateAlbum нормализует и проверяет тело запроса и переводит его в альбом.
+// Без вида альбом считается полноформатным (LP).
+func validateAlbum(input *models.AlbumInput) (models.Album, error) {
+	var v validation.Errors
+
+	v.String("title", input.Title, validation.AlbumTitle)
+	v.ID("group", input.Group)
+	if input.GroupName != nil {
+		if input.Group != nil {
+			v.Add("group", "укажите только group или group_name")
+		}
+		v.String("group_name", input.GroupName, validation.GroupName)
+	}
+	v.AlbumType("type", &input.Type)
+	v.Link("cover", input.Cover)
+
+	album := models.Album{
+		Title: input.Title,
+		Group: input.Group,
+		Type:  input.Type,
+		Cover: input.Cover,
+	}
+	if album.Type == "" {
+		album.Type = models.AlbumLP
+	}
+	if album.Cover != nil && *album.Cover == "" {
+		album.Cover = nil
+	}
+
+	if input.ReleaseDate != nil {
+		date, precision, err := models.ParseDate(*input.ReleaseDate)
+		if err != nil {
+			v.Add("releaseDate", "допустимые форматы: YYYY-MM-DD, DD.MM.YYYY, YYYY-MM, YYYY")
+		} else {
+			v.ReleaseDate("releaseDate", &date)
+		}
+		album.ReleaseDate = &date
+		album.ReleaseDatePrecision = precision
+	}
+
+	return album, v.Err()
+}
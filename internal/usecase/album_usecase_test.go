@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"context"
+	"effectiveMobile/internal/storage"
+	"effectiveMobile/models"
+	"errors"
+	"io"
+	"log"
+	"testing"
+)
+
+func TestSongAlbumGroup(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+	s := storage.NewMemorySongStorage(logger, logger)
+	songs := NewSongUsecase(s, nil, logger, logger)
+	albums := NewAlbumUsecase(s, logger, logger)
+
+	muse, err := s.AddGroup(ctx, models.Group{Name: ptr("Muse")})
+	if err != nil {
+		t.Fatalf("AddGroup: %v", err)
+	}
+	placebo, err := s.AddGroup(ctx, models.Group{Name: ptr("Placebo")})
+	if err != nil {
+		t.Fatalf("AddGroup: %v", err)
+	}
+	absolution, err := albums.CreateAlbum(ctx, models.AlbumInput{Title: ptr("Absolution"), Group: &muse})
+	if err != nil {
+		t.Fatalf("CreateAlbum: %v", err)
+	}
+	sampler, err := albums.CreateAlbum(ctx, models.AlbumInput{Title: ptr("Sampler"), Type: models.AlbumCompilation})
+	if err != nil {
+		t.Fatalf("CreateAlbum: %v", err)
+	}
+	hysteria, err := s.AddSong(ctx, models.Song{Group: &muse, Name: ptr("Hysteria")})
+	if err != nil {
+		t.Fatalf("AddSong: %v", err)
+	}
+	pureMorning, err := s.AddSong(ctx, models.Song{Group: &placebo, Name: ptr("Pure Morning")})
+	if err != nil {
+		t.Fatalf("AddSong: %v", err)
+	}
+
+	patch := func(id int, patch models.SongPatch) error {
+		_, err := songs.PatchSong(ctx, id, patch, AnyVersion)
+		return err
+	}
+
+	if err := patch(pureMorning, models.SongPatch{Album: models.Set(absolution)}); !errors.Is(err, ErrValidation) {
+		t.Errorf("песня в альбоме другой группы: ошибка %v, ожидалась ошибка проверки", err)
+	}
+	if err := patch(pureMorning, models.SongPatch{Album: models.Set(sampler)}); err != nil {
+		t.Errorf("песня в сборнике без группы: %v", err)
+	}
+	if err := patch(pureMorning, models.SongPatch{Album: models.Set(1000)}); !errors.Is(err, ErrValidation) {
+		t.Errorf("песня в несуществующем альбоме: ошибка %v, ожидалась ошибка проверки", err)
+	}
+	if err := patch(hysteria, models.SongPatch{Album: models.Set(absolution), TrackNumber: models.Set(8)}); err != nil {
+		t.Fatalf("песня в альбоме своей группы: %v", err)
+	}
+
+	// Смена группы проверяется по альбому, который уже есть у песни
+	if err := patch(hysteria, models.SongPatch{Group: models.Set(placebo)}); !errors.Is(err, ErrValidation) {
+		t.Errorf("смена группы песни в альбоме: ошибка %v, ожидалась ошибка проверки", err)
+	}
+	if err := patch(hysteria, models.SongPatch{Group: models.Set(placebo), Album: models.PatchField[int]{Set: true}}); err != nil {
+		t.Errorf("смена группы вместе с удалением из альбома: %v", err)
+	}
+}
+
+func TestAlbumGroupChange(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+	s := storage.NewMemorySongStorage(logger, logger)
+	songs := NewSongUsecase(s, nil, logger, logger)
+	albums := NewAlbumUsecase(s, logger, logger)
+
+	muse, err := s.AddGroup(ctx, models.Group{Name: ptr("Muse")})
+	if err != nil {
+		t.Fatalf("AddGroup: %v", err)
+	}
+	placebo, err := s.AddGroup(ctx, models.Group{Name: ptr("Placebo")})
+	if err != nil {
+		t.Fatalf("AddGroup: %v", err)
+	}
+	absolution, err := albums.CreateAlbum(ctx, models.AlbumInput{Title: ptr("Absolution"), Group: &muse})
+	if err != nil {
+		t.Fatalf("CreateAlbum: %v", err)
+	}
+	hysteria, err := s.AddSong(ctx, models.Song{Group: &muse, Name: ptr("Hysteria")})
+	if err != nil {
+		t.Fatalf("AddSong: %v", err)
+	}
+	if _, err := songs.PatchSong(ctx, hysteria, models.SongPatch{Album: models.Set(absolution)}, AnyVersion); err != nil {
+		t.Fatalf("PatchSong: %v", err)
+	}
+
+	// Песни альбома остались бы у другой группы
+	err = albums.UpdateAlbum(ctx, absolution, models.AlbumInput{Title: ptr("Absolution"), Group: &placebo})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Fields[0].Field != "group" {
+		t.Errorf("смена группы альбома с песнями: ошибка %v, ожидалась ошибка поля group", err)
+	}
+	if album, _ := s.GetAlbumByID(ctx, absolution); album.Group == nil || *album.Group != muse {
+		t.Errorf("группа альбома после отказа: %v", album.Group)
+	}
+
+	if err := albums.UpdateAlbum(ctx, absolution, models.AlbumInput{Title: ptr("Absolution"), Group: &muse}); err != nil {
+		t.Errorf("альбом со своей группой: %v", err)
+	}
+	if err := albums.UpdateAlbum(ctx, absolution, models.AlbumInput{Title: ptr("Absolution")}); err != nil {
+		t.Errorf("альбом без группы: %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package usecase
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("сервис информации о песнях временно недоступен")
+
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half-open"
+)
+
+// BreakerStats — снимок состояния автоматического выключателя
+type BreakerStats struct {
+	State               BreakerState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutiveFailures"`
+	TotalSuccesses      int64        `json:"totalSuccesses"`
+	TotalFailures       int64        `json:"totalFailures"`
+	Rejected            int64        `json:"rejected"`
+	OpenedAt            *time.Time   `json:"openedAt,omitempty"`
+	NextProbeAt         *time.Time   `json:"nextProbeAt,omitempty"`
+	LastError           string       `json:"lastError,omitempty"`
+}
+
+// circuitBreaker размыкается после failureThreshold ошибок подряд.
+// Через openTimeout он переходит в полуоткрытое состояние и пропускает
+// один пробный запрос: успех замыкает цепь, ошибка снова размыкает её.
+type circuitBreaker struct {
+	mu sync.Mutex
+
+	failureThreshold int
+	openTimeout      time.Duration
+	now              func() time.Time
+
+	state         BreakerState
+	failures      int
+	openedAt      time.Time
+	probeInFlight bool
+
+	totalSuccesses int64
+	totalFailures  int64
+	rejected       int64
+	lastError      string
+}
+
+func newCircuitBreaker(failureThreshold int, openTimeout time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		failureThreshold: failureThreshold,
+		openTimeout:      openTimeout,
+		now:              time.Now,
+		state:            BreakerClosed,
+	}
+}
+
+// allow проверяет, можно ли выполнить запрос к внешнему сервису
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.openTimeout {
+			b.rejected++
+			return ErrCircuitOpen
+		}
+		b.state = BreakerHalfOpen
+		b.probeInFlight = false
+		fallthrough
+	case BreakerHalfOpen:
+		if b.probeInFlight {
+			b.rejected++
+			return ErrCircuitOpen
+		}
+		b.probeInFlight = true
+	}
+
+	return nil
+}
+
+func (b *circuitBreaker) onSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = BreakerClosed
+	b.failures = 0
+	b.probeInFlight = false
+	b.totalSuccesses++
+}
+
+// release освобождает место пробного запроса, на который не получено
+// ответа, не меняя состояния выключателя и счётчика ошибок
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probeInFlight = false
+}
+
+func (b *circuitBreaker) onFailure(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.totalFailures++
+	b.lastError = err.Error()
+
+	if b.state == BreakerHalfOpen || b.failures >= b.failureThreshold {
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+		b.probeInFlight = false
+	}
+}
+
+func (b *circuitBreaker) stats() BreakerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := BreakerStats{
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		TotalSuccesses:      b.totalSuccesses,
+		TotalFailures:       b.totalFailures,
+		Rejected:            b.rejected,
+		LastError:           b.lastError,
+	}
+
+	if b.state != BreakerClosed {
+		openedAt := b.openedAt
+		nextProbeAt := b.openedAt.Add(b.openTimeout)
+		stats.OpenedAt = &openedAt
+		stats.NextProbeAt = &nextProbeAt
+	}
+
+	return stats
+}
@@ -0,0 +1,146 @@
+package usecase
+
+import (
+	"context"
+	"effectiveMobile/internal/storage"
+	"effectiveMobile/models"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EnrichmentConfig задаёт параметры повторного обогащения песен
+type EnrichmentConfig struct {
+	BatchSize   int
+	Concurrency int
+	MaxAttempts int
+	RetryDelay  time.Duration
+}
+
+func DefaultEnrichmentConfig() EnrichmentConfig {
+	return EnrichmentConfig{
+		BatchSize:   50,
+		Concurrency: 4,
+		MaxAttempts: 5,
+		RetryDelay:  time.Minute,
+	}
+}
+
+// EnrichmentResult — итог одного прохода обогащения
+type EnrichmentResult struct {
+	Scanned  int
+	Enriched int
+	Failed   int
+	// Skipped — песни, до которых не дошла очередь из-за разомкнутого выключателя
+	Skipped int
+}
+
+type EnrichmentUsecase interface {
+	EnrichIncompleteSongs(ctx context.Context) (EnrichmentResult, error)
+}
+
+type enrichmentUsecase struct {
+	songStorage storage.SongStorage
+	infoClient  SongInfoClient
+	config      EnrichmentConfig
+	infoLog     *log.Logger
+	errorLog    *log.Logger
+}
+
+func NewEnrichmentUsecase(s storage.SongStorage, infoClient SongInfoClient, config EnrichmentConfig, infoLog, errorLog *log.Logger) EnrichmentUsecase {
+	return &enrichmentUsecase{
+		songStorage: s,
+		infoClient:  infoClient,
+		config:      config,
+		infoLog:     infoLog,
+		errorLog:    errorLog,
+	}
+}
+
+// EnrichIncompleteSongs обрабатывает одну пачку неполных песен,
+// одновременно выполняя не больше config.Concurrency запросов к внешнему API.
+// Если выключатель внешнего API разомкнут, пачка заканчивается досрочно:
+// оставшиеся песни дождутся следующего прохода, не расходуя попыток.
+func (uc *enrichmentUsecase) EnrichIncompleteSongs(ctx context.Context) (EnrichmentResult, error) {
+	var result EnrichmentResult
+
+	songs, err := uc.songStorage.GetIncompleteSongs(ctx, uc.config.BatchSize, uc.config.MaxAttempts, uc.config.RetryDelay)
+	if err != nil {
+		return result, err
+	}
+	result.Scanned = len(songs)
+
+	var enriched, failed, skipped atomic.Int64
+	var circuitOpen atomic.Bool
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, max(uc.config.Concurrency, 1))
+
+	for i, song := range songs {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return result, ctx.Err()
+		case sem <- struct{}{}:
+		}
+
+		if circuitOpen.Load() {
+			<-sem
+			skipped.Add(int64(len(songs) - i))
+			break
+		}
+
+		wg.Add(1)
+		go func(song models.Song) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := uc.enrichOne(ctx, song)
+			switch {
+			case err == nil:
+				enriched.Add(1)
+			case errors.Is(err, ErrCircuitOpen):
+				circuitOpen.Store(true)
+				skipped.Add(1)
+			default:
+				failed.Add(1)
+			}
+		}(song)
+	}
+
+	wg.Wait()
+
+	result.Enriched = int(enriched.Load())
+	result.Failed = int(failed.Load())
+	result.Skipped = int(skipped.Load())
+
+	return result, nil
+}
+
+// enrichOne дополняет одну песню. Отказ разомкнутого выключателя не
+// считается попыткой: запроса к внешнему API не было.
+func (uc *enrichmentUsecase) enrichOne(ctx context.Context, song models.Song) error {
+	if song.ID == nil || song.Name == nil || song.Group_name == nil {
+		return errors.New("у песни нет ID, названия или группы")
+	}
+
+	detail, err := uc.infoClient.GetSongInfo(ctx, *song.Group_name, *song.Name)
+	if err != nil {
+		uc.errorLog.Printf("Ошибка обогащения песни %d: %v", *song.ID, err)
+		if ctx.Err() == nil && !errors.Is(err, ErrCircuitOpen) {
+			// Без записанной попытки песня будет выбираться снова без задержки
+			if markErr := uc.songStorage.MarkEnrichmentFailed(ctx, *song.ID, err.Error()); markErr != nil {
+				uc.errorLog.Printf("Не удалось сохранить неудачную попытку обогащения песни %d: %v", *song.ID, markErr)
+				return errors.Join(err, markErr)
+			}
+		}
+		return err
+	}
+
+	if err := applySongDetail(&song, detail); err != nil {
+		uc.errorLog.Printf("Неправильная дата релиза %q у песни %d: %v", detail.ReleaseDate, *song.ID, err)
+	}
+
+	return uc.songStorage.SaveEnrichment(ctx, *song.ID, song)
+}
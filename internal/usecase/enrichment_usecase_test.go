@@ -0,0 +1,101 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"effectiveMobile/internal/storage"
+	"effectiveMobile/models"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnrichmentStopsWhenCircuitOpen(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+	s := storage.NewMemorySongStorage(logger, logger)
+
+	groupID, err := s.AddGroup(ctx, models.Group{Name: ptr("Muse")})
+	if err != nil {
+		t.Fatalf("AddGroup: %v", err)
+	}
+	for _, name := range []string{"Starlight", "Hysteria", "Uprising"} {
+		if _, err := s.AddSong(ctx, models.Song{Group: &groupID, Name: ptr(name)}); err != nil {
+			t.Fatalf("AddSong(%q): %v", name, err)
+		}
+	}
+
+	calls := 0
+	client := songInfoFunc(func(ctx context.Context, group, song string) (SongDetail, error) {
+		calls++
+		return SongDetail{}, ErrCircuitOpen
+	})
+	config := EnrichmentConfig{BatchSize: 10, Concurrency: 1, MaxAttempts: 1, RetryDelay: time.Hour}
+	enrichment := NewEnrichmentUsecase(s, client, config, logger, logger)
+
+	result, err := enrichment.EnrichIncompleteSongs(ctx)
+	if err != nil {
+		t.Fatalf("EnrichIncompleteSongs: %v", err)
+	}
+	if calls != 1 || result.Skipped != 3 || result.Failed != 0 {
+		t.Errorf("проход при разомкнутом выключателе: %+v, запросов %d", result, calls)
+	}
+
+	// Попытки не израсходованы: песни выбираются снова даже при MaxAttempts = 1
+	songs, err := s.GetIncompleteSongs(ctx, 10, 1, time.Hour)
+	if err != nil {
+		t.Fatalf("GetIncompleteSongs: %v", err)
+	}
+	if len(songs) != 3 {
+		t.Errorf("после прохода при разомкнутом выключателе осталось %d песен для обогащения, ожидалось 3", len(songs))
+	}
+}
+
+// failingMarkStorage не может сохранить неудачную попытку обогащения
+type failingMarkStorage struct {
+	storage.SongStorage
+}
+
+func (s failingMarkStorage) MarkEnrichmentFailed(ctx context.Context, id int, reason string) error {
+	return errors.New("база недоступна")
+}
+
+func TestEnrichmentMarkFailedError(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+	var errorLog bytes.Buffer
+	s := storage.NewMemorySongStorage(logger, logger)
+
+	groupID, err := s.AddGroup(ctx, models.Group{Name: ptr("Muse")})
+	if err != nil {
+		t.Fatalf("AddGroup: %v", err)
+	}
+	if _, err := s.AddSong(ctx, models.Song{Group: &groupID, Name: ptr("Starlight")}); err != nil {
+		t.Fatalf("AddSong: %v", err)
+	}
+
+	client := songInfoFunc(func(ctx context.Context, group, song string) (SongDetail, error) {
+		return SongDetail{}, &SongInfoStatusError{StatusCode: http.StatusBadGateway}
+	})
+	config := EnrichmentConfig{BatchSize: 10, Concurrency: 1, MaxAttempts: 3, RetryDelay: time.Hour}
+	enrichment := NewEnrichmentUsecase(failingMarkStorage{s}, client, config, logger, log.New(&errorLog, "", 0))
+
+	result, err := enrichment.EnrichIncompleteSongs(ctx)
+	if err != nil {
+		t.Fatalf("EnrichIncompleteSongs: %v", err)
+	}
+	if result.Failed != 1 {
+		t.Errorf("результат прохода: %+v", result)
+	}
+	if !strings.Contains(errorLog.String(), "Не удалось сохранить неудачную попытку") {
+		t.Errorf("ошибка записи попытки не попала в журнал: %q", errorLog.String())
+	}
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
@@ -0,0 +1,34 @@
+package usecase
+
+import (
+	"effectiveMobile/internal/storage"
+	"effectiveMobile/internal/validation"
+)
+
+// Ошибки хранилища пробрасываются без изменений, поэтому обработчикам
+// достаточно сравнивать ошибки с константами usecase
+var (
+	ErrNotFound           = storage.ErrNotFound
+	ErrConflict           = storage.ErrConflict
+	ErrPreconditionFailed = storage.ErrVersionMismatch
+	ErrGroupNotEmpty      = storage.ErrGroupNotEmpty
+	ErrValidation         = validation.ErrInvalid
+)
+
+// ConflictError — ErrConflict с ID песни, которая уже есть у группы
+type ConflictError = storage.ConflictError
+
+// AnyVersion отключает проверку версии песни (If-Match: *)
+const AnyVersion = storage.AnyVersion
+
+// FieldError описывает неправильное значение одного поля или параметра
+type FieldError = validation.FieldError
+
+// ValidationError перечисляет все неправильные поля запроса.
+// errors.Is(err, ErrValidation) истинно для любой ValidationError.
+type ValidationError = validation.Error
+
+// InvalidField возвращает ошибку проверки одного поля
+func InvalidField(field, message string) error {
+	return validation.Field(field, message)
+}
@@ -0,0 +1,218 @@
+package usecase
+
+import (
+	"context"
+	"effectiveMobile/internal/storage"
+	"effectiveMobile/internal/validation"
+	"effectiveMobile/models"
+	"errors"
+	"fmt"
+)
+
+const (
+	maxMergeSources = 50
+	// mergeBatchSize — сколько песен объединяемой группы читается за раз
+	mergeBatchSize = 100
+	// maxRenameAttempts ограничивает перебор номеров для стратегии rename
+	maxRenameAttempts = 100
+)
+
+// MergeGroups переносит песни групп merge.SourceIDs в группу merge.TargetID
+// и оставляет ID объединённых групп псевдонимами целевой. Всё объединение
+// выполняется в одной транзакции: при ошибке ни одна группа не меняется.
+func (uc *groupUsecase) MergeGroups(ctx context.Context, merge models.GroupMerge) (models.GroupMergeResult, error) {
+	var v validation.Errors
+	validateGroupMerge(&merge, &v)
+	if err := v.Err(); err != nil {
+		return models.GroupMergeResult{}, err
+	}
+
+	var result models.GroupMergeResult
+	err := uc.storage.WithTx(ctx, func(tx storage.SongStorage) error {
+		result = models.GroupMergeResult{}
+
+		if _, err := tx.GetGroupByID(ctx, merge.TargetID); err != nil {
+			return groupNotFound("target_id", merge.TargetID, err)
+		}
+
+		for _, sourceID := range merge.SourceIDs {
+			if _, err := tx.GetGroupByID(ctx, sourceID); err != nil {
+				return groupNotFound("source_ids", sourceID, err)
+			}
+		}
+
+		for _, sourceID := range merge.SourceIDs {
+			if err := mergeGroupSongs(ctx, tx, sourceID, merge, &result); err != nil {
+				return err
+			}
+			if err := tx.AliasGroup(ctx, sourceID, merge.TargetID); err != nil {
+				return err
+			}
+		}
+
+		group, err := tx.GetGroupByID(ctx, merge.TargetID)
+		result.Group = group
+		return err
+	})
+	if err != nil {
+		return models.GroupMergeResult{}, err
+	}
+
+	uc.infoLog.Printf("Группы %v объединены в группу %d: перенесено %d, слито %d, переименовано %d",
+		merge.SourceIDs, merge.TargetID, result.Moved, result.Merged, result.Renamed)
+	return result, nil
+}
+
+// groupNotFound сообщает о несуществующей группе как об ошибке поля запроса
+func groupNotFound(field string, id int, err error) error {
+	if errors.Is(err, ErrNotFound) {
+		return InvalidField(field, fmt.Sprintf("группа %d не найдена", id))
+	}
+	return err
+}
+
+// mergeGroupSongs переносит все песни группы sourceID в целевую группу
+func mergeGroupSongs(ctx context.Context, tx storage.SongStorage, sourceID int, merge models.GroupMerge, result *models.GroupMergeResult) error {
+	filter := models.SongFilter{GroupID: &sourceID}
+	for {
+		// Каждая обработанная песня уходит из группы, поэтому читается всегда первая страница
+		page, err := tx.GetAllSongs(ctx, filter, models.Pagination{Page: 1, Limit: mergeBatchSize})
+		if err != nil {
+			return err
+		}
+		if len(page.Items) == 0 {
+			return nil
+		}
+
+		for _, song := range page.Items {
+			if err := mergeSong(ctx, tx, song, merge, result); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// mergeSong переносит песню в целевую группу. Совпадение названия
+// проверяется заранее: ошибка ограничения прервала бы транзакцию Postgres.
+func mergeSong(ctx context.Context, tx storage.SongStorage, song models.Song, merge models.GroupMerge, result *models.GroupMergeResult) error {
+	move := models.SongChanges{GroupID: models.Set(merge.TargetID)}
+
+	existingID, err := tx.FindSongByName(ctx, merge.TargetID, *song.Name)
+	if errors.Is(err, ErrNotFound) {
+		result.Moved++
+		return tx.PatchSong(ctx, *song.ID, move, AnyVersion)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch merge.Strategy {
+	case models.MergeKeepTarget:
+		result.Merged++
+		return mergeDuplicate(ctx, tx, existingID, *song.ID)
+	case models.MergeKeepSource:
+		result.Merged++
+		if err := mergeDuplicate(ctx, tx, *song.ID, existingID); err != nil {
+			return err
+		}
+		return tx.PatchSong(ctx, *song.ID, move, AnyVersion)
+	case models.MergeRename:
+		result.Renamed++
+		return renameSong(ctx, tx, song, merge.TargetID)
+	default:
+		return &ConflictError{ID: existingID}
+	}
+}
+
+// mergeDuplicate дополняет пустые поля песни keepID данными песни dropID
+// и удаляет песню dropID
+func mergeDuplicate(ctx context.Context, tx storage.SongStorage, keepID, dropID int) error {
+	kept, err := tx.GetSongByID(ctx, keepID)
+	if err != nil {
+		return err
+	}
+	dropped, err := tx.GetSongByID(ctx, dropID)
+	if err != nil {
+		return err
+	}
+
+	if changes := missingSongFields(kept, dropped); !changes.Empty() {
+		if err := tx.PatchSong(ctx, keepID, changes, AnyVersion); err != nil {
+			return err
+		}
+	}
+	return tx.DeleteSong(ctx, dropID, AnyVersion)
+}
+
+// missingSongFields возвращает поля песни from, которых нет у песни to
+func missingSongFields(to, from models.Song) models.SongChanges {
+	var changes models.SongChanges
+	if to.ReleaseDate == nil && from.ReleaseDate != nil {
+		changes.ReleaseDate = models.Set(*from.ReleaseDate)
+		changes.ReleaseDatePrecision = from.ReleaseDatePrecision
+	}
+	if to.Text == nil && from.Text != nil {
+		changes.Text = models.Set(*from.Text)
+	}
+	if to.Link == nil && from.Link != nil {
+		changes.Link = models.Set(*from.Link)
+	}
+	return changes
+}
+
+// renameSong переносит песню в группу groupID под первым свободным
+// названием вида «Numb (2)»
+func renameSong(ctx context.Context, tx storage.SongStorage, song models.Song, groupID int) error {
+	for n := 2; n <= maxRenameAttempts; n++ {
+		name := fmt.Sprintf("%s (%d)", *song.Name, n)
+
+		_, err := tx.FindSongByName(ctx, groupID, name)
+		if errors.Is(err, ErrNotFound) {
+			changes := models.SongChanges{Name: models.Set(name), GroupID: models.Set(groupID)}
+			return tx.PatchSong(ctx, *song.ID, changes, AnyVersion)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return fmt.Errorf("не удалось подобрать свободное название для песни %q", *song.Name)
+}
+
+// validateGroupMerge проверяет запрос на объединение и убирает повторы групп
+func validateGroupMerge(merge *models.GroupMerge, v *validation.Errors) {
+	if merge.TargetID <= 0 {
+		v.Add("target_id", "должно быть положительным числом")
+	}
+
+	switch {
+	case len(merge.SourceIDs) == 0:
+		v.Add("source_ids", "нужна хотя бы одна группа")
+	case len(merge.SourceIDs) > maxMergeSources:
+		v.Add("source_ids", fmt.Sprintf("не больше %d групп", maxMergeSources))
+	}
+
+	seen := make(map[int]bool, len(merge.SourceIDs))
+	sources := merge.SourceIDs[:0]
+	for i, id := range merge.SourceIDs {
+		field := fmt.Sprintf("source_ids[%d]", i)
+		switch {
+		case id <= 0:
+			v.Add(field, "должно быть положительным числом")
+		case id == merge.TargetID:
+			v.Add(field, "группа не может быть объединена сама с собой")
+		case !seen[id]:
+			seen[id] = true
+			sources = append(sources, id)
+		}
+	}
+	merge.SourceIDs = sources
+
+	switch merge.Strategy {
+	case "":
+		merge.Strategy = models.MergeKeepTarget
+	case models.MergeKeepTarget, models.MergeKeepSource, models.MergeRename, models.MergeError:
+	default:
+		v.Add("strategy", "допустимые значения: keep_target, keep_source, rename, error")
+	}
+}
@@ -0,0 +1,250 @@
+package usecase
+
+import (
+	"context"
+	"effectiveMobile/internal/storage"
+	"effectiveMobile/models"
+	"errors"
+	"io"
+	"log"
+	"testing"
+)
+
+// mergeFixture — две группы-дубликата, у которых совпадает песня «Numb»:
+// у целевой группы у неё есть ссылка, у объединяемой — текст
+type mergeFixture struct {
+	storage                storage.SongStorage
+	groups                 GroupUsecase
+	target, source         int
+	targetNumb, sourceNumb int
+	crawling               int
+}
+
+func newMergeFixture(t *testing.T) mergeFixture {
+	t.Helper()
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+	s := storage.NewMemorySongStorage(logger, logger)
+
+	f := mergeFixture{storage: s, groups: NewGroupUsecase(s, logger, logger)}
+	var err error
+	if f.target, err = s.AddGroup(ctx, models.Group{Name: ptr("Linkin Park")}); err != nil {
+		t.Fatalf("AddGroup: %v", err)
+	}
+	if f.source, err = s.CreateGroup(ctx, models.Group{Name: ptr("linkin park")}); err != nil {
+		t.Fatalf("CreateGroup: %v", err)
+	}
+
+	add := func(group int, song models.Song) int {
+		song.Group = &group
+		id, err := s.AddSong(ctx, song)
+		if err != nil {
+			t.Fatalf("AddSong(%q): %v", *song.Name, err)
+		}
+		return id
+	}
+	f.targetNumb = add(f.target, models.Song{Name: ptr("Numb"), Link: ptr("https://example.com/numb")})
+	f.sourceNumb = add(f.source, models.Song{Name: ptr("NUMB"), Text: ptr("I'm tired of being what you want me to be")})
+	f.crawling = add(f.source, models.Song{Name: ptr("Crawling")})
+
+	return f
+}
+
+func (f mergeFixture) merge(t *testing.T, strategy models.MergeStrategy) (models.GroupMergeResult, error) {
+	t.Helper()
+	return f.groups.MergeGroups(context.Background(), models.GroupMerge{
+		TargetID:  f.target,
+		SourceIDs: []int{f.source},
+		Strategy:  strategy,
+	})
+}
+
+// targetSongs возвращает песни целевой группы по названию
+func (f mergeFixture) targetSongs(t *testing.T) map[string]models.Song {
+	t.Helper()
+	page, err := f.storage.GetAllSongs(context.Background(),
+		models.SongFilter{GroupID: &f.target}, models.Pagination{Page: 1, Limit: 10})
+	if err != nil {
+		t.Fatalf("GetAllSongs: %v", err)
+	}
+
+	songs := make(map[string]models.Song, len(page.Items))
+	for _, song := range page.Items {
+		songs[*song.Name] = song
+	}
+	return songs
+}
+
+// checkMerged проверяет, что объединённая группа стала псевдонимом целевой
+func (f mergeFixture) checkMerged(t *testing.T) {
+	t.Helper()
+	ctx := context.Background()
+
+	if _, err := f.storage.GetGroupByID(ctx, f.source); !errors.Is(err, ErrNotFound) {
+		t.Errorf("объединённая группа осталась: %v", err)
+	}
+	if id, err := f.storage.ResolveGroupAlias(ctx, f.source); err != nil || id != f.target {
+		t.Errorf("ResolveGroupAlias = %d, %v, ожидался %d", id, err, f.target)
+	}
+}
+
+func TestMergeGroupsKeepTarget(t *testing.T) {
+	f := newMergeFixture(t)
+
+	result, err := f.merge(t, "")
+	if err != nil {
+		t.Fatalf("MergeGroups: %v", err)
+	}
+	if result.Moved != 1 || result.Merged != 1 || result.Renamed != 0 || result.Group.ID != f.target {
+		t.Errorf("результат объединения: %+v", result)
+	}
+	f.checkMerged(t)
+
+	songs := f.targetSongs(t)
+	if len(songs) != 2 || songs["Numb"].ID == nil || *songs["Numb"].ID != f.targetNumb {
+		t.Fatalf("песни целевой группы: %v", songs)
+	}
+	// Пустой текст дополнен из дубликата, ссылка своя
+	numb, _ := f.storage.GetSongByID(context.Background(), f.targetNumb)
+	if numb.Text == nil || numb.Link == nil || *numb.Link != "https://example.com/numb" {
+		t.Errorf("оставшаяся песня: %+v", numb)
+	}
+	if _, err := f.storage.GetSongByID(context.Background(), f.sourceNumb); !errors.Is(err, ErrNotFound) {
+		t.Errorf("дубликат не удалён: %v", err)
+	}
+}
+
+func TestMergeGroupsKeepSource(t *testing.T) {
+	f := newMergeFixture(t)
+
+	result, err := f.merge(t, models.MergeKeepSource)
+	if err != nil {
+		t.Fatalf("MergeGroups: %v", err)
+	}
+	if result.Moved != 1 || result.Merged != 1 {
+		t.Errorf("результат объединения: %+v", result)
+	}
+	f.checkMerged(t)
+
+	songs := f.targetSongs(t)
+	if moved, ok := songs["NUMB"]; len(songs) != 2 || !ok || *moved.ID != f.sourceNumb {
+		t.Fatalf("песни целевой группы: %v", songs)
+	}
+	// Пустая ссылка дополнена из песни целевой группы
+	numb, _ := f.storage.GetSongByID(context.Background(), f.sourceNumb)
+	if numb.Text == nil || numb.Link == nil || *numb.Link != "https://example.com/numb" {
+		t.Errorf("перенесённая песня: %+v", numb)
+	}
+	if _, err := f.storage.GetSongByID(context.Background(), f.targetNumb); !errors.Is(err, ErrNotFound) {
+		t.Errorf("песня целевой группы не удалена: %v", err)
+	}
+}
+
+func TestMergeGroupsRename(t *testing.T) {
+	f := newMergeFixture(t)
+
+	result, err := f.merge(t, models.MergeRename)
+	if err != nil {
+		t.Fatalf("MergeGroups: %v", err)
+	}
+	if result.Moved != 1 || result.Merged != 0 || result.Renamed != 1 {
+		t.Errorf("результат объединения: %+v", result)
+	}
+	f.checkMerged(t)
+
+	songs := f.targetSongs(t)
+	renamed, ok := songs["NUMB (2)"]
+	if len(songs) != 3 || !ok || *renamed.ID != f.sourceNumb {
+		t.Errorf("песни целевой группы: %v", songs)
+	}
+}
+
+func TestMergeGroupsError(t *testing.T) {
+	f := newMergeFixture(t)
+	ctx := context.Background()
+
+	_, err := f.merge(t, models.MergeError)
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) || conflict.ID != f.targetNumb {
+		t.Fatalf("MergeGroups: ошибка %v, ожидался конфликт с песней %d", err, f.targetNumb)
+	}
+
+	// Транзакция откатилась целиком, включая уже перенесённые песни
+	if _, err := f.storage.GetGroupByID(ctx, f.source); err != nil {
+		t.Errorf("объединяемая группа пропала: %v", err)
+	}
+	song, err := f.storage.GetSongByID(ctx, f.crawling)
+	if err != nil || *song.Group_name != "linkin park" {
+		t.Errorf("песня объединяемой группы после отмены: %+v, %v", song, err)
+	}
+	if len(f.targetSongs(t)) != 1 {
+		t.Errorf("песни целевой группы после отмены: %v", f.targetSongs(t))
+	}
+}
+
+func TestMergedGroupIDOnWrite(t *testing.T) {
+	f := newMergeFixture(t)
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+
+	if _, err := f.merge(t, models.MergeRename); err != nil {
+		t.Fatalf("MergeGroups: %v", err)
+	}
+
+	songs := NewSongUsecase(f.storage, nil, logger, logger)
+	id, _, err := songs.AddSong(ctx, models.Song{Group: &f.source, Name: ptr("Faint")}, "")
+	if err != nil {
+		t.Fatalf("AddSong со старым ID группы: %v", err)
+	}
+	if song, _ := f.storage.GetSongByID(ctx, id); *song.Group_name != "Linkin Park" {
+		t.Errorf("песня добавлена в группу %q", *song.Group_name)
+	}
+
+	patch := models.SongPatch{Group: models.Set(f.source)}
+	if _, err := songs.PatchSong(ctx, id, patch, AnyVersion); err != nil {
+		t.Errorf("PatchSong со старым ID группы: %v", err)
+	}
+
+	albums := NewAlbumUsecase(f.storage, logger, logger)
+	albumID, err := albums.CreateAlbum(ctx, models.AlbumInput{Title: ptr("Meteora"), Group: &f.source})
+	if err != nil {
+		t.Fatalf("CreateAlbum со старым ID группы: %v", err)
+	}
+	if album, _ := f.storage.GetAlbumByID(ctx, albumID); album.Group == nil || *album.Group != f.target {
+		t.Errorf("альбом добавлен в группу %v", album.Group)
+	}
+
+	if err := f.groups.UpdateGroup(ctx, f.source, models.Group{Name: ptr("Linkin Park!")}); err != nil {
+		t.Fatalf("UpdateGroup по старому ID: %v", err)
+	}
+	if group, _ := f.storage.GetGroupByID(ctx, f.target); *group.Name != "Linkin Park!" {
+		t.Errorf("целевая группа после UpdateGroup по старому ID: %q", *group.Name)
+	}
+
+	if err := f.groups.DeleteGroup(ctx, f.source, true); err != nil {
+		t.Fatalf("DeleteGroup по старому ID: %v", err)
+	}
+	if _, err := f.storage.GetGroupByID(ctx, f.target); !errors.Is(err, ErrNotFound) {
+		t.Errorf("целевая группа после DeleteGroup по старому ID: %v", err)
+	}
+}
+
+func TestMergedGroupNameOnWrite(t *testing.T) {
+	f := newMergeFixture(t)
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+
+	if _, err := f.merge(t, ""); err != nil {
+		t.Fatalf("MergeGroups: %v", err)
+	}
+
+	// Написание влитой группы ведёт в целевую, а не создаёт дубликат заново
+	songs := NewSongUsecase(f.storage, nil, logger, logger)
+	id, _, err := songs.AddSong(ctx, models.Song{Group_name: ptr("linkin park "), Name: ptr("Faint")}, "")
+	if err != nil {
+		t.Fatalf("AddSong: %v", err)
+	}
+	if song, _ := f.storage.GetSongByID(ctx, id); *song.Group_name != "Linkin Park" {
+		t.Errorf("песня добавлена в группу %q", *song.Group_name)
+	}
+}
@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"context"
+	"effectiveMobile/internal/storage"
+	"effectiveMobile/internal/validation"
+	"effectiveMobile/models"
+	"errors"
+	"log"
+)
+
+const (
+	defaultGroupsLimit = 20
+	maxGroupsLimit     = 100
+)
+
+type GroupUsecase interface {
+	GetAllGroups(ctx context.Context, filter models.GroupFilter, page models.Pagination) (models.GroupPage, error)
+	GetGroupByID(ctx context.Context, id int) (models.Group, error)
+	// GetGroupSongs возвращает страницу песен группы с сортировкой и курсором, как список песен
+	GetGroupSongs(ctx context.Context, id int, page models.Pagination) (models.SongPage, error)
+	CreateGroup(ctx context.Context, group models.Group) (int, error)
+	UpdateGroup(ctx context.Context, id int, group models.Group) error
+	// DeleteGroup с cascade удаляет группу вместе с песнями,
+	// без него для группы с песнями возвращает ErrGroupNotEmpty
+	DeleteGroup(ctx context.Context, id int, cascade bool) error
+	// MergeGroups объединяет группы-дубликаты в одну, совпадения названий
+	// песен разрешаются стратегией merge.Strategy
+	MergeGroups(ctx context.Context, merge models.GroupMerge) (models.GroupMergeResult, error)
+}
+
+type groupUsecase struct {
+	storage  storage.SongStorage
+	infoLog  *log.Logger
+	errorLog *log.Logger
+}
+
+func NewGroupUsecase(s storage.SongStorage, infoLog, errorLog *log.Logger) GroupUsecase {
+	return &groupUsecase{
+		storage:  s,
+		infoLog:  infoLog,
+		errorLog: errorLog,
+	}
+}
+
+func (uc *groupUsecase) GetAllGroups(ctx context.Context, filter models.GroupFilter, page models.Pagination) (models.GroupPage, error) {
+	var v validation.Errors
+	page.Page, page.Limit = normalizePage(page.Page, page.Limit, defaultGroupsLimit, maxGroupsLimit, &v)
+	if err := v.Err(); err != nil {
+		return models.GroupPage{}, err
+	}
+
+	return uc.storage.GetAllGroups(ctx, filter, page)
+}
+
+// GetGroupByID по ID объединённой группы возвращает группу, в которую
+// её влили; ID в ответе тогда отличается от запрошенного
+func (uc *groupUsecase) GetGroupByID(ctx context.Context, id int) (models.Group, error) {
+	id, err := resolveGroupID(ctx, uc.storage, id)
+	if err != nil {
+		return models.Group{}, err
+	}
+	return uc.storage.GetGroupByID(ctx, id)
+}
+
+func (uc *groupUsecase) GetGroupSongs(ctx context.Context, id int, page models.Pagination) (models.SongPage, error) {
+	// У несуществующей группы нет и пустого списка песен
+	group, err := uc.GetGroupByID(ctx, id)
+	if err != nil {
+		return models.SongPage{}, err
+	}
+
+	return listSongs(ctx, uc.storage, models.SongFilter{GroupID: &group.ID}, page)
+}
+
+func (uc *groupUsecase) CreateGroup(ctx context.Context, group models.Group) (int, error) {
+	var v validation.Errors
+	validateGroup(&group, &v)
+	if err := v.Err(); err != nil {
+		return 0, err
+	}
+
+	return uc.storage.CreateGroup(ctx, group)
+}
+
+func (uc *groupUsecase) UpdateGroup(ctx context.Context, id int, group models.Group) error {
+	var v validation.Errors
+	validateGroup(&group, &v)
+	if err := v.Err(); err != nil {
+		return err
+	}
+
+	id, err := resolveGroupID(ctx, uc.storage, id)
+	if err != nil {
+		return err
+	}
+	return uc.storage.UpdateGroup(ctx, id, group)
+}
+
+func (uc *groupUsecase) DeleteGroup(ctx context.Context, id int, cascade bool) error {
+	id, err := resolveGroupID(ctx, uc.storage, id)
+	if err != nil {
+		return err
+	}
+	return uc.storage.DeleteGroup(ctx, id, cascade)
+}
+
+// resolveGroupID возвращает ID группы, в которую влита группа id.
+// Если id не псевдоним, он возвращается без изменений. Старые ID
+// объединённых групп принимаются везде, где группа задаётся по ID.
+func resolveGroupID(ctx context.Context, s storage.SongStorage, id int) (int, error) {
+	groupID, err := s.ResolveGroupAlias(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return id, nil
+	}
+	return groupID, err
+}
+
+// existingGroupID возвращает ID группы, переданной в поле field тела запроса,
+// с учётом псевдонимов. Несуществующая группа — ошибка поля (422), а не 404:
+// ресурс из пути запроса при этом может существовать.
+func existingGroupID(ctx context.Context, s storage.SongStorage, field string, id int) (int, error) {
+	groupID, err := resolveGroupID(ctx, s, id)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := s.GetGroupByID(ctx, groupID); err != nil {
+		return 0, groupNotFound(field, id, err)
+	}
+	return groupID, nil
+}
+
+// validateGroup нормализует название и описание группы перед сохранением
+func validateGroup(group *models.Group, v *validation.Errors) {
+	v.String("group", group.Name, validation.GroupName)
+	v.Country("country", group.Country)
+	v.FormedYear("formedYear", group.FormedYear)
+	v.Genres("genres", &group.Genres)
+	v.String("bio", group.Bio, validation.Bio)
+}
@@ -0,0 +1,29 @@
+package usecase
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	defaultVersesLimit = 1
+	maxVersesLimit     = 50
+)
+
+// Куплеты разделяются пустыми строками, в том числе содержащими пробелы
+var verseSeparator = regexp.MustCompile(`\n[ \t]*\n`)
+
+// splitVerses разбивает текст песни на куплеты по пустым строкам
+func splitVerses(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	verses := make([]string, 0)
+	for _, verse := range verseSeparator.Split(text, -1) {
+		verse = strings.TrimSpace(verse)
+		if verse != "" {
+			verses = append(verses, verse)
+		}
+	}
+
+	return verses
+}
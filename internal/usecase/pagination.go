@@ -0,0 +1,31 @@
+package usecase
+
+import (
+	"effectiveMobile/internal/validation"
+	"fmt"
+)
+
+const (
+	defaultSongsLimit = 20
+	maxSongsLimit     = 100
+)
+
+// normalizePage подставляет значения по умолчанию и проверяет границы.
+// Нулевые page и limit означают, что параметры не переданы.
+func normalizePage(page, limit, defaultLimit, maxLimit int, v *validation.Errors) (int, int) {
+	if page == 0 {
+		page = 1
+	}
+	if limit == 0 {
+		limit = defaultLimit
+	}
+
+	if page < 1 {
+		v.Add("page", "должно быть не меньше 1")
+	}
+	if limit < 1 || limit > maxLimit {
+		v.Add("limit", fmt.Sprintf("должно быть от 1 до %d", maxLimit))
+	}
+
+	return page, limit
+}
@@ -0,0 +1,143 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// ResilienceConfig задаёт таймауты, повторы и параметры выключателя
+// для запросов к внешнему API
+type ResilienceConfig struct {
+	Timeout          time.Duration
+	MaxRetries       int
+	BaseBackoff      time.Duration
+	MaxBackoff       time.Duration
+	FailureThreshold int
+	OpenTimeout      time.Duration
+}
+
+func DefaultResilienceConfig() ResilienceConfig {
+	return ResilienceConfig{
+		Timeout:          2 * time.Second,
+		MaxRetries:       3,
+		BaseBackoff:      100 * time.Millisecond,
+		MaxBackoff:       2 * time.Second,
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+	}
+}
+
+// ResilientSongInfoClient — клиент внешнего API с защитой от медленного
+// или нестабильного сервиса. Состояние выключателя доступно через BreakerStats.
+type ResilientSongInfoClient interface {
+	SongInfoClient
+	BreakerStats() BreakerStats
+}
+
+type resilientSongInfoClient struct {
+	next    SongInfoClient
+	config  ResilienceConfig
+	breaker *circuitBreaker
+}
+
+func NewResilientSongInfoClient(next SongInfoClient, config ResilienceConfig) ResilientSongInfoClient {
+	return &resilientSongInfoClient{
+		next:    next,
+		config:  config,
+		breaker: newCircuitBreaker(config.FailureThreshold, config.OpenTimeout),
+	}
+}
+
+func (c *resilientSongInfoClient) GetSongInfo(ctx context.Context, group, song string) (SongDetail, error) {
+	var lastErr error
+
+	for attempt := 0; attempt <= c.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if err := sleepContext(ctx, c.backoff(attempt)); err != nil {
+				return SongDetail{}, err
+			}
+		}
+
+		if err := c.breaker.allow(); err != nil {
+			if lastErr != nil {
+				return SongDetail{}, errors.Join(err, lastErr)
+			}
+			return SongDetail{}, err
+		}
+
+		detail, err := c.call(ctx, group, song)
+		if err == nil || errors.Is(err, ErrSongInfoNotFound) {
+			// Сервис ответил корректно, даже если песни у него нет
+			c.breaker.onSuccess()
+			return detail, err
+		}
+
+		if ctx.Err() != nil {
+			// Запрос отменён вызывающей стороной: ответа сервиса не было,
+			// поэтому состояние выключателя не меняется
+			c.breaker.release()
+			return SongDetail{}, ctx.Err()
+		}
+
+		c.breaker.onFailure(err)
+		lastErr = err
+
+		if !isRetryable(err) {
+			break
+		}
+	}
+
+	return SongDetail{}, lastErr
+}
+
+func (c *resilientSongInfoClient) BreakerStats() BreakerStats {
+	return c.breaker.stats()
+}
+
+// call выполняет одну попытку запроса с собственным таймаутом
+func (c *resilientSongInfoClient) call(ctx context.Context, group, song string) (SongDetail, error) {
+	callCtx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+
+	return c.next.GetSongInfo(callCtx, group, song)
+}
+
+// backoff возвращает экспоненциальную задержку перед попыткой attempt.
+// Половина задержки фиксирована, вторая половина случайна, чтобы
+// одновременные клиенты не повторяли запросы синхронно.
+func (c *resilientSongInfoClient) backoff(attempt int) time.Duration {
+	delay := c.config.BaseBackoff << (attempt - 1)
+	if delay <= 0 || delay > c.config.MaxBackoff {
+		delay = c.config.MaxBackoff
+	}
+
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+
+	return half + rand.N(half)
+}
+
+func isRetryable(err error) bool {
+	var statusErr *SongInfoStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Temporary()
+	}
+
+	return true
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// songInfoFunc позволяет подставить функцию вместо клиента внешнего API
+type songInfoFunc func(ctx context.Context, group, song string) (SongDetail, error)
+
+func (f songInfoFunc) GetSongInfo(ctx context.Context, group, song string) (SongDetail, error) {
+	return f(ctx, group, song)
+}
+
+func TestResilientClientCancelledProbe(t *testing.T) {
+	errUnavailable := errors.New("503")
+	var next songInfoFunc
+
+	client := NewResilientSongInfoClient(songInfoFunc(func(ctx context.Context, group, song string) (SongDetail, error) {
+		return next(ctx, group, song)
+	}), ResilienceConfig{Timeout: time.Second, FailureThreshold: 2, OpenTimeout: time.Minute}).(*resilientSongInfoClient)
+
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	client.breaker.now = func() time.Time { return now }
+
+	// closed: отменённый запрос не сбрасывает счётчик ошибок
+	next = func(ctx context.Context, group, song string) (SongDetail, error) {
+		return SongDetail{}, errUnavailable
+	}
+	client.GetSongInfo(context.Background(), "Muse", "Starlight")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	next = func(callCtx context.Context, group, song string) (SongDetail, error) {
+		cancel()
+		return SongDetail{}, callCtx.Err()
+	}
+	if _, err := client.GetSongInfo(ctx, "Muse", "Starlight"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("отменённый запрос: ошибка %v, ожидалась context.Canceled", err)
+	}
+	if stats := client.BreakerStats(); stats.State != BreakerClosed || stats.ConsecutiveFailures != 1 || stats.TotalSuccesses != 0 {
+		t.Errorf("после отмены в closed: %+v", stats)
+	}
+
+	// closed -> open
+	next = func(ctx context.Context, group, song string) (SongDetail, error) {
+		return SongDetail{}, errUnavailable
+	}
+	client.GetSongInfo(context.Background(), "Muse", "Starlight")
+	if stats := client.BreakerStats(); stats.State != BreakerOpen {
+		t.Fatalf("после %d ошибок подряд: %+v", stats.ConsecutiveFailures, stats)
+	}
+	if _, err := client.GetSongInfo(context.Background(), "Muse", "Starlight"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("запрос при разомкнутой цепи: ошибка %v, ожидалась ErrCircuitOpen", err)
+	}
+
+	// open -> half-open: пробный запрос отменён, цепь не замыкается
+	now = now.Add(2 * time.Minute)
+	ctx, cancel = context.WithCancel(context.Background())
+	next = func(callCtx context.Context, group, song string) (SongDetail, error) {
+		cancel()
+		return SongDetail{}, callCtx.Err()
+	}
+	if _, err := client.GetSongInfo(ctx, "Muse", "Starlight"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("отменённый пробный запрос: ошибка %v, ожидалась context.Canceled", err)
+	}
+	if stats := client.BreakerStats(); stats.State != BreakerHalfOpen || stats.ConsecutiveFailures != 2 || stats.TotalSuccesses != 0 {
+		t.Errorf("после отменённого пробного запроса: %+v", stats)
+	}
+
+	// Место пробного запроса освобождено: следующий запрос доходит до сервиса
+	next = func(ctx context.Context, group, song string) (SongDetail, error) {
+		return SongDetail{ReleaseDate: "16.07.2006"}, nil
+	}
+	if _, err := client.GetSongInfo(context.Background(), "Muse", "Starlight"); err != nil {
+		t.Fatalf("пробный запрос после отмены: %v", err)
+	}
+	if stats := client.BreakerStats(); stats.State != BreakerClosed || stats.ConsecutiveFailures != 0 {
+		t.Errorf("после успешного пробного запроса: %+v", stats)
+	}
+}
@@ -0,0 +1,43 @@
+package usecase
+
+import (
+	"effectiveMobile/internal/validation"
+	"effectiveMobile/models"
+	"slices"
+	"strings"
+)
+
+// Порог похожести по умолчанию совпадает с порогом pg_trgm
+const defaultSimilarityThreshold = 0.3
+
+// validateSongFilter подставляет режим сравнения по умолчанию и проверяет фильтр
+func validateSongFilter(filter *models.SongFilter, v *validation.Errors) {
+	switch filter.Match {
+	case "":
+		filter.Match = models.MatchContains
+	case models.MatchContains, models.MatchPrefix, models.MatchExact:
+	default:
+		v.Add("match", "допустимые значения: contains, prefix, exact")
+	}
+
+	if filter.ReleasedFrom != nil && filter.ReleasedTo != nil && filter.ReleasedFrom.After(*filter.ReleasedTo) {
+		v.Add("from", "дата начала позже даты окончания")
+	}
+}
+
+// validateSongSort проверяет, что поля сортировки разрешены и не повторяются
+func validateSongSort(sort []models.SortField, v *validation.Errors) {
+	seen := make(map[string]bool, len(sort))
+
+	for _, field := range sort {
+		switch {
+		case !slices.Contains(models.SongSortFields, field.Field):
+			v.Add("sort", "допустимые поля: "+strings.Join(models.SongSortFields, ", "))
+			return
+		case seen[field.Field]:
+			v.Add("sort", "поле "+field.Field+" указано дважды")
+			return
+		}
+		seen[field.Field] = true
+	}
+}
@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"context"
+	"effectiveMobile/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var ErrSongInfoNotFound = errors.New("информация о песне не найдена")
+
+// SongInfoStatusError — неожиданный HTTP статус от внешнего API
+type SongInfoStatusError struct {
+	StatusCode int
+}
+
+func (e *SongInfoStatusError) Error() string {
+	return fmt.Sprintf("сервис информации о песнях вернул статус %d", e.StatusCode)
+}
+
+// Temporary сообщает, имеет ли смысл повторить запрос
+func (e *SongInfoStatusError) Temporary() bool {
+	return e.StatusCode >= http.StatusInternalServerError || e.StatusCode == http.StatusTooManyRequests
+}
+
+// SongDetail — ответ внешнего API на запрос GET /info
+type SongDetail struct {
+	ReleaseDate string `json:"releaseDate"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+}
+
+// SongInfoClient получает информацию о песне из внешнего сервиса
+type SongInfoClient interface {
+	GetSongInfo(ctx context.Context, group, song string) (SongDetail, error)
+}
+
+type httpSongInfoClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewSongInfoClient(baseURL string, httpClient *http.Client) SongInfoClient {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &httpSongInfoClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+func (c *httpSongInfoClient) GetSongInfo(ctx context.Context, group, song string) (SongDetail, error) {
+	var detail SongDetail
+
+	params := url.Values{}
+	params.Set("group", group)
+	params.Set("song", song)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/info?"+params.Encode(), nil)
+	if err != nil {
+		return detail, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return detail, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return detail, ErrSongInfoNotFound
+	case resp.StatusCode != http.StatusOK:
+		return detail, &SongInfoStatusError{StatusCode: resp.StatusCode}
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&detail)
+	return detail, err
+}
+
+// applySongDetail переносит данные внешнего API в песню.
+// Текст и ссылка переносятся, даже если дату релиза разобрать не удалось.
+func applySongDetail(song *models.Song, detail SongDetail) error {
+	if detail.Text != "" {
+		song.Text = &detail.Text
+	}
+	if detail.Link != "" {
+		song.Link = &detail.Link
+	}
+
+	// Внешний API отдаёт даты в формате DD.MM.YYYY, но иногда знает только год
+	if detail.ReleaseDate == "" {
+		song.ReleaseDate = nil
+		return nil
+	}
+
+	date, precision, err := models.ParseDate(detail.ReleaseDate)
+	if err != nil {
+		song.ReleaseDate = nil
+		return err
+	}
+
+	song.ReleaseDate, song.ReleaseDatePrecision = &date, precision
+	return nil
+}
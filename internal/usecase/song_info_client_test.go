@@ -0,0 +1,50 @@
+package usecase_test
+
+import (
+	"context"
+	"effectiveMobile/internal/infostub"
+	"effectiveMobile/internal/storage"
+	"effectiveMobile/internal/usecase"
+	"effectiveMobile/models"
+	"io"
+	"log"
+	"testing"
+	"time"
+)
+
+func TestAddSongWithInfoStub(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+
+	stub := infostub.NewServer()
+	defer stub.Close()
+	stub.Add("Muse", "Uprising", usecase.SongDetail{
+		ReleaseDate: "07.09.2009",
+		Text:        "Paranoia is in bloom",
+		Link:        "https://www.youtube.com/watch?v=w8KQmps-Sog",
+	})
+
+	s := storage.NewMemorySongStorage(logger, logger)
+	songs := usecase.NewSongUsecase(s, usecase.NewSongInfoClient(stub.URL, nil), logger, logger)
+
+	group, name := "Muse", "Uprising"
+	id, _, err := songs.AddSong(ctx, models.Song{Group_name: &group, Name: &name}, "")
+	if err != nil {
+		t.Fatalf("AddSong: %v", err)
+	}
+
+	song, err := s.GetSongByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetSongByID: %v", err)
+	}
+	if song.ReleaseDate == nil || *song.ReleaseDate != models.NewDate(2009, time.September, 7) ||
+		song.ReleaseDatePrecision != models.PrecisionDay {
+		t.Errorf("дата релиза: %v (%s), ожидалась 2009-09-07", song.ReleaseDate, song.ReleaseDatePrecision)
+	}
+	if song.Text == nil || *song.Text != "Paranoia is in bloom" {
+		t.Errorf("текст песни: %v", song.Text)
+	}
+	if song.Link == nil || *song.Link != "https://www.youtube.com/watch?v=w8KQmps-Sog" {
+		t.Errorf("ссылка: %v", song.Link)
+	}
+}
@@ -3,53 +3,446 @@ package usecase
 import (
 	"context"
 	"effectiveMobile/internal/storage"
+	"effectiveMobile/internal/validation"
 	"effectiveMobile/models"
+	"errors"
+	"fmt"
 	"log"
+	"strings"
 )
 
 type SongUsecase interface {
-	GetAllSongs(ctx context.Context, filterName, filterGroup string) ([]models.Song, error)
+	GetAllSongs(ctx context.Context, filter models.SongFilter, page models.Pagination) (models.SongPage, error)
 	GetSongByID(ctx context.Context, id int) (models.Song, error)
-	AddSong(ctx context.Context, song models.Song) error
-	UpdateSong(ctx context.Context, id int, song models.Song) error
-	DeleteSong(ctx context.Context, id int) error
+	SearchSongs(ctx context.Context, search models.SearchQuery, page models.Pagination) (models.SongSearchPage, error)
+	FuzzySearchSongs(ctx context.Context, query string, threshold *float64, limit int) (models.FuzzySongPage, error)
+	GetSongLyrics(ctx context.Context, id, page, limit int) (models.LyricsPage, error)
+	// AddSong возвращает ID песни и признак того, что она создана, а не найдена
+	AddSong(ctx context.Context, song models.Song, onConflict models.OnConflict) (int, bool, error)
+	// version — версия песни из If-Match, AnyVersion отключает проверку
+	UpdateSong(ctx context.Context, id int, song models.Song, version int) error
+	PatchSong(ctx context.Context, id int, patch models.SongPatch, version int) (models.Song, error)
+	DeleteSong(ctx context.Context, id int, version int) error
 	AddGroup(ctx context.Context, group models.Group) (int, error)
 }
 
 type songUsecase struct {
 	songStorage storage.SongStorage
+	infoClient  SongInfoClient
 	infoLog     *log.Logger
 	errorLog    *log.Logger
 }
 
-func NewSongUsecase(s storage.SongStorage, infoLog, errorLog *log.Logger) SongUsecase {
+// NewSongUsecase создаёт usecase песен. Если infoClient равен nil,
+// песни добавляются без обогащения данными из внешнего API.
+func NewSongUsecase(s storage.SongStorage, infoClient SongInfoClient, infoLog, errorLog *log.Logger) SongUsecase {
 	return &songUsecase{
 		songStorage: s,
+		infoClient:  infoClient,
 		infoLog:     infoLog,
 		errorLog:    errorLog,
 	}
 }
 
-func (uc *songUsecase) GetAllSongs(ctx context.Context, filterName, filterGroup string) ([]models.Song, error) {
-	return uc.songStorage.GetAllSongs(ctx, filterName, filterGroup)
+func (uc *songUsecase) GetAllSongs(ctx context.Context, filter models.SongFilter, page models.Pagination) (models.SongPage, error) {
+	return listSongs(ctx, uc.songStorage, filter, page)
+}
+
+// listSongs проверяет фильтр и параметры страницы и возвращает страницу песен
+func listSongs(ctx context.Context, songStorage storage.SongStorage, filter models.SongFilter, page models.Pagination) (models.SongPage, error) {
+	var v validation.Errors
+	validateSongFilter(&filter, &v)
+	validateSongSort(page.Sort, &v)
+
+	if page.After != "" && page.Page != 0 {
+		// Курсор и номер страницы взаимоисключают друг друга
+		v.Add("after", "нельзя передавать вместе с page")
+	}
+	page.Page, page.Limit = normalizePage(page.Page, page.Limit, defaultSongsLimit, maxSongsLimit, &v)
+
+	if err := v.Err(); err != nil {
+		return models.SongPage{}, err
+	}
+
+	// Старый ID объединённой группы выдаёт песни группы, в которую её влили
+	if filter.GroupID != nil {
+		groupID, err := resolveGroupID(ctx, songStorage, *filter.GroupID)
+		if err != nil {
+			return models.SongPage{}, err
+		}
+		filter.GroupID = &groupID
+	}
+
+	songs, err := songStorage.GetAllSongs(ctx, filter, page)
+	if errors.Is(err, storage.ErrInvalidCursor) {
+		return songs, InvalidField("after", "курсор повреждён или выдан для другой сортировки")
+	}
+	return songs, err
 }
 
 func (uc *songUsecase) GetSongByID(ctx context.Context, id int) (models.Song, error) {
 	return uc.songStorage.GetSongByID(ctx, id)
 }
 
-func (uc *songUsecase) AddSong(ctx context.Context, song models.Song) error {
-	return uc.songStorage.AddSong(ctx, song)
+// SearchSongs выполняет полнотекстовый поиск по названию, группе и тексту песни
+func (uc *songUsecase) SearchSongs(ctx context.Context, search models.SearchQuery, page models.Pagination) (models.SongSearchPage, error) {
+	var v validation.Errors
+
+	search.Text = strings.TrimSpace(search.Text)
+	if search.Text == "" {
+		v.Add("q", "поисковый запрос не задан")
+	}
+
+	switch search.Lang {
+	case models.SearchLangAny, models.SearchLangRu, models.SearchLangEn:
+	default:
+		v.Add("lang", "допустимые значения: ru, en")
+	}
+
+	page.Page, page.Limit = normalizePage(page.Page, page.Limit, defaultSongsLimit, maxSongsLimit, &v)
+
+	if err := v.Err(); err != nil {
+		return models.SongSearchPage{}, err
+	}
+
+	return uc.songStorage.SearchSongs(ctx, search, page)
+}
+
+// FuzzySearchSongs ищет песни с опечатками в названии или группе.
+// Без threshold берётся порог по умолчанию, явный 0 находит любые песни;
+// нулевой limit заменяется значением по умолчанию.
+func (uc *songUsecase) FuzzySearchSongs(ctx context.Context, query string, threshold *float64, limit int) (models.FuzzySongPage, error) {
+	var v validation.Errors
+
+	query = strings.TrimSpace(query)
+	if query == "" {
+		v.Add("q", "поисковый запрос не задан")
+	}
+
+	minSimilarity := defaultSimilarityThreshold
+	if threshold != nil {
+		minSimilarity = *threshold
+	}
+	// Сравнение записано так, чтобы NaN тоже не прошёл проверку
+	if !(minSimilarity >= 0 && minSimilarity <= 1) {
+		v.Add("threshold", "должно быть от 0 до 1")
+	}
+
+	_, limit = normalizePage(1, limit, defaultSongsLimit, maxSongsLimit, &v)
+
+	if err := v.Err(); err != nil {
+		return models.FuzzySongPage{}, err
+	}
+
+	return uc.songStorage.FuzzySearchSongs(ctx, query, minSimilarity, limit)
+}
+
+// GetSongLyrics возвращает страницу куплетов песни и общее число куплетов
+func (uc *songUsecase) GetSongLyrics(ctx context.Context, id, page, limit int) (models.LyricsPage, error) {
+	var v validation.Errors
+	page, limit = normalizePage(page, limit, defaultVersesLimit, maxVersesLimit, &v)
+	if err := v.Err(); err != nil {
+		return models.LyricsPage{}, err
+	}
+
+	song, err := uc.songStorage.GetSongByID(ctx, id)
+	if err != nil {
+		return models.LyricsPage{}, err
+	}
+
+	var verses []string
+	if song.Text != nil {
+		verses = splitVerses(*song.Text)
+	}
+
+	lyrics := models.LyricsPage{
+		SongID: id,
+		Verses: []string{},
+		Page:   page,
+		Limit:  limit,
+		Total:  len(verses),
+	}
+
+	start := (page - 1) * limit
+	if start < len(verses) {
+		end := min(start+limit, len(verses))
+		lyrics.Verses = verses[start:end]
+	}
+
+	return lyrics, nil
+}
+
+// AddSong добавляет песню и возвращает её ID. Если у песни задано только
+// название группы, группа создаётся в той же транзакции, что и песня:
+// при ошибке добавления песни группа без песен не остаётся.
+// Если у группы уже есть песня с таким названием, onConflict выбирает,
+// вернуть ли ConflictError, существующую песню или обновить её.
+func (uc *songUsecase) AddSong(ctx context.Context, song models.Song, onConflict models.OnConflict) (int, bool, error) {
+	var v validation.Errors
+	validateSong(&song, &v)
+	switch onConflict {
+	case "":
+		onConflict = models.OnConflictError
+	case models.OnConflictError, models.OnConflictReturn, models.OnConflictUpdate:
+	default:
+		v.Add("on_conflict", "допустимые значения: error, return, update")
+	}
+	if err := v.Err(); err != nil {
+		return 0, false, err
+	}
+
+	// Внешний API опрашиваем до начала транзакции, чтобы не держать её открытой
+	if uc.infoClient != nil && song.Group_name != nil && song.Name != nil {
+		uc.enrichSong(ctx, &song)
+	}
+
+	var id int
+	created := true
+	err := uc.songStorage.WithTx(ctx, func(tx storage.SongStorage) error {
+		if song.Group != nil {
+			groupID, err := existingGroupID(ctx, tx, "group", *song.Group)
+			if err != nil {
+				return err
+			}
+			song.Group = &groupID
+		}
+		if song.Group == nil && song.Group_name != nil {
+			groupID, err := tx.AddGroup(ctx, models.Group{Name: song.Group_name})
+			if err != nil {
+				return err
+			}
+			song.Group = &groupID
+		}
+
+		var err error
+		id, err = tx.AddSong(ctx, song)
+
+		var conflict *ConflictError
+		if onConflict == models.OnConflictError || !errors.As(err, &conflict) {
+			return err
+		}
+
+		id, created = conflict.ID, false
+		if onConflict == models.OnConflictUpdate {
+			return tx.PatchSong(ctx, id, existingSongChanges(song), AnyVersion)
+		}
+		return nil
+	})
+	return id, created, err
+}
+
+// existingSongChanges переносит в уже существующую песню написание
+// названия и данные внешнего API; поля, которых нет, не очищаются
+func existingSongChanges(song models.Song) models.SongChanges {
+	changes := models.SongChanges{Name: models.Set(*song.Name)}
+	if song.ReleaseDate != nil {
+		changes.ReleaseDate = models.Set(*song.ReleaseDate)
+		changes.ReleaseDatePrecision = song.ReleaseDatePrecision
+	}
+	if song.Text != nil {
+		changes.Text = models.Set(*song.Text)
+	}
+	if song.Link != nil {
+		changes.Link = models.Set(*song.Link)
+	}
+	return changes
+}
+
+// enrichSong дополняет песню датой релиза, текстом и ссылкой из внешнего API.
+// Ошибки внешнего API не мешают добавлению песни: если сервис недоступен,
+// песня сохраняется без данных и помечается для повторного обогащения.
+func (uc *songUsecase) enrichSong(ctx context.Context, song *models.Song) {
+	uc.infoLog.Printf("Запрашиваем информацию о песне %q группы %q", *song.Name, *song.Group_name)
+
+	detail, err := uc.infoClient.GetSongInfo(ctx, *song.Group_name, *song.Name)
+	if errors.Is(err, ErrSongInfoNotFound) {
+		uc.infoLog.Printf("Внешний API не знает песню %q группы %q", *song.Name, *song.Group_name)
+		return
+	}
+	if err != nil {
+		uc.errorLog.Printf("Ошибка получения информации о песне, сохраняем без обогащения: %v", err)
+		song.NeedsEnrichment = true
+		return
+	}
+
+	if err := applySongDetail(song, detail); err != nil {
+		uc.errorLog.Printf("Неправильная дата релиза %q: %v", detail.ReleaseDate, err)
+	}
+}
+
+// UpdateSong заменяет песню целиком через те же проверки, что и PatchSong:
+// поля, которых нет в теле, очищаются. Группу очистить нельзя, поэтому
+// без group и group_name песня остаётся в своей группе.
+func (uc *songUsecase) UpdateSong(ctx context.Context, id int, newSong models.Song, version int) error {
+	patch := replacementPatch(newSong)
+
+	return uc.songStorage.WithTx(ctx, func(tx storage.SongStorage) error {
+		changes, err := songChanges(ctx, tx, id, patch)
+		if err != nil {
+			return err
+		}
+		return tx.PatchSong(ctx, id, changes, version)
+	})
+}
+
+// replacementPatch переводит новое представление песни в патч,
+// в котором заданы все поля песни
+func replacementPatch(song models.Song) models.SongPatch {
+	patch := models.SongPatch{
+		Name:        models.PatchField[string]{Set: true, Value: song.Name},
+		Text:        models.PatchField[string]{Set: true, Value: song.Text},
+		Link:        models.PatchField[string]{Set: true, Value: song.Link},
+		ReleaseDate: models.PatchField[string]{Set: true},
+		Album:       models.PatchField[int]{Set: true, Value: song.AlbumID},
+		DiscNumber:  models.PatchField[int]{Set: true, Value: song.DiscNumber},
+		TrackNumber: models.PatchField[int]{Set: true, Value: song.TrackNumber},
+	}
+	if song.ReleaseDate != nil {
+		patch.ReleaseDate = models.Set(song.ReleaseDate.FormatPrecision(song.ReleaseDatePrecision))
+	}
+
+	if song.Group != nil {
+		patch.Group = models.Set(*song.Group)
+	}
+	if song.Group_name != nil {
+		patch.GroupName = models.Set(*song.Group_name)
+	}
+	return patch
+}
+
+// PatchSong применяет merge patch (RFC 7396): меняются только переданные
+// поля, явный null очищает поле. Группа по названию создаётся, если её нет,
+// в одной транзакции с изменением песни.
+func (uc *songUsecase) PatchSong(ctx context.Context, id int, patch models.SongPatch, version int) (models.Song, error) {
+	var song models.Song
+	err := uc.songStorage.WithTx(ctx, func(tx storage.SongStorage) error {
+		changes, err := songChanges(ctx, tx, id, patch)
+		if err != nil {
+			return err
+		}
+
+		if changes.Empty() {
+			// Пустой патч ничего не меняет, но версию всё равно нужно проверить
+			song, err = tx.GetSongByID(ctx, id)
+			if err == nil && version != AnyVersion && song.Version != version {
+				err = ErrPreconditionFailed
+			}
+			return err
+		}
+
+		if err := tx.PatchSong(ctx, id, changes, version); err != nil {
+			return err
+		}
+
+		song, err = tx.GetSongByID(ctx, id)
+		return err
+	})
+	return song, err
+}
+
+// songChanges проверяет документ merge patch песни id и переводит его в изменения для хранилища
+func songChanges(ctx context.Context, tx storage.SongStorage, id int, patch models.SongPatch) (models.SongChanges, error) {
+	var v validation.Errors
+	validateSongPatch(&patch, &v)
+
+	// Поля патча уже нормализованы проверкой
+	changes := models.SongChanges{
+		Name:        patch.Name,
+		GroupID:     patch.Group,
+		Text:        patch.Text,
+		Link:        patch.Link,
+		AlbumID:     patch.Album,
+		DiscNumber:  patch.DiscNumber,
+		TrackNumber: patch.TrackNumber,
+	}
+
+	if patch.ReleaseDate.Set {
+		changes.ReleaseDate.Set = true
+		if patch.ReleaseDate.Value != nil {
+			date, precision, err := models.ParseDate(*patch.ReleaseDate.Value)
+			if err != nil {
+				v.Add("releaseDate", "допустимые форматы: YYYY-MM-DD, DD.MM.YYYY, YYYY-MM, YYYY")
+			} else {
+				v.ReleaseDate("releaseDate", &date)
+			}
+			changes.ReleaseDate.Value = &date
+			changes.ReleaseDatePrecision = precision
+		}
+	}
+
+	if err := v.Err(); err != nil {
+		return changes, err
+	}
+
+	if patch.Group.Set {
+		groupID, err := existingGroupID(ctx, tx, "group", *patch.Group.Value)
+		if err != nil {
+			return changes, err
+		}
+		changes.GroupID = models.Set(groupID)
+	}
+	if patch.GroupName.Set {
+		groupID, err := tx.AddGroup(ctx, models.Group{Name: patch.GroupName.Value})
+		if err != nil {
+			return changes, err
+		}
+		changes.GroupID = models.Set(groupID)
+	}
+
+	if (changes.AlbumID.Set && changes.AlbumID.Value != nil) || changes.GroupID.Set {
+		if err := checkAlbumGroup(ctx, tx, id, changes); err != nil {
+			return changes, err
+		}
+	}
+
+	return changes, nil
 }
 
-func (uc *songUsecase) UpdateSong(ctx context.Context, id int, newSong models.Song) error {
-	return uc.songStorage.UpdateSong(ctx, id, newSong)
+// checkAlbumGroup проверяет, что альбом песни после изменений принадлежит
+// её группе. Песня может быть в альбоме без группы, например в сборнике.
+func checkAlbumGroup(ctx context.Context, tx storage.SongStorage, id int, changes models.SongChanges) error {
+	song, err := tx.GetSongByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	albumID := song.AlbumID
+	if changes.AlbumID.Set {
+		albumID = changes.AlbumID.Value
+	}
+	if albumID == nil {
+		return nil
+	}
+
+	album, err := tx.GetAlbumByID(ctx, *albumID)
+	if errors.Is(err, ErrNotFound) {
+		return InvalidField("album", fmt.Sprintf("альбом %d не найден", *albumID))
+	}
+	if err != nil || album.Group == nil {
+		return err
+	}
+
+	// Представление песни содержит только название группы, а оно уникально
+	sameGroup := song.Group_name != nil && album.GroupName != nil && *album.GroupName == *song.Group_name
+	if changes.GroupID.Set {
+		sameGroup = *album.Group == *changes.GroupID.Value
+	}
+	if !sameGroup {
+		return InvalidField("album", fmt.Sprintf("альбом %d принадлежит другой группе", *albumID))
+	}
+	return nil
 }
 
-func (uc *songUsecase) DeleteSong(ctx context.Context, id int) error {
-	return uc.songStorage.DeleteSong(ctx, id)
+func (uc *songUsecase) DeleteSong(ctx context.Context, id int, version int) error {
+	return uc.songStorage.DeleteSong(ctx, id, version)
 }
 
 func (uc *songUsecase) AddGroup(ctx context.Context, group models.Group) (int, error) {
+	var v validation.Errors
+	v.String("group", group.Name, validation.GroupName)
+	if err := v.Err(); err != nil {
+		return 0, err
+	}
+
 	return uc.songStorage.AddGroup(ctx, group)
 }
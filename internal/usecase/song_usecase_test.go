@@ -0,0 +1,151 @@
+package usecase
+
+import (
+	"context"
+	"effectiveMobile/internal/storage"
+	"effectiveMobile/models"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestUnknownGroupInBody(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+	s := storage.NewMemorySongStorage(logger, logger)
+	songs := NewSongUsecase(s, nil, logger, logger)
+	albums := NewAlbumUsecase(s, logger, logger)
+
+	groupID, err := s.AddGroup(ctx, models.Group{Name: ptr("Muse")})
+	if err != nil {
+		t.Fatalf("AddGroup: %v", err)
+	}
+	songID, err := s.AddSong(ctx, models.Song{Group: &groupID, Name: ptr("Starlight")})
+	if err != nil {
+		t.Fatalf("AddSong: %v", err)
+	}
+
+	// Группа из тела запроса не найдена — это ошибка поля, а не 404
+	checks := map[string]error{}
+	_, _, checks["AddSong"] = songs.AddSong(ctx, models.Song{Group: ptr(1000), Name: ptr("Uprising")}, "")
+	_, checks["PatchSong"] = songs.PatchSong(ctx, songID, models.SongPatch{Group: models.Set(1000)}, AnyVersion)
+	_, checks["CreateAlbum"] = albums.CreateAlbum(ctx, models.AlbumInput{Title: ptr("Drones"), Group: ptr(1000)})
+
+	for name, err := range checks {
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) || len(validationErr.Fields) != 1 || validationErr.Fields[0].Field != "group" {
+			t.Errorf("%s с несуществующей группой: ошибка %v, ожидалась ошибка поля group", name, err)
+		}
+	}
+}
+
+func TestFuzzySearchThreshold(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+	s := storage.NewMemorySongStorage(logger, logger)
+	songs := NewSongUsecase(s, nil, logger, logger)
+
+	groupID, err := s.AddGroup(ctx, models.Group{Name: ptr("Muse")})
+	if err != nil {
+		t.Fatalf("AddGroup: %v", err)
+	}
+	for _, name := range []string{"Hysteria", "Starlight"} {
+		if _, err := s.AddSong(ctx, models.Song{Group: &groupID, Name: ptr(name)}); err != nil {
+			t.Fatalf("AddSong: %v", err)
+		}
+	}
+
+	// Без порога берётся значение по умолчанию, явный 0 находит любые песни
+	tests := []struct {
+		threshold *float64
+		found     int
+	}{
+		{nil, 1},
+		{ptr(0.0), 2},
+		{ptr(1.0), 0},
+	}
+	for _, tt := range tests {
+		page, err := songs.FuzzySearchSongs(ctx, "Histeria", tt.threshold, 0)
+		if err != nil {
+			t.Errorf("порог %v: %v", tt.threshold, err)
+			continue
+		}
+		if len(page.Items) != tt.found {
+			t.Errorf("порог %v: найдено %d песен, ожидалось %d", tt.threshold, len(page.Items), tt.found)
+		}
+	}
+
+	for _, threshold := range []float64{-0.1, 1.5, math.NaN()} {
+		_, err := songs.FuzzySearchSongs(ctx, "Histeria", &threshold, 0)
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) || validationErr.Fields[0].Field != "threshold" {
+			t.Errorf("порог %v: ошибка %v, ожидалась ошибка поля threshold", threshold, err)
+		}
+	}
+}
+
+func TestUpdateSongReplacesAllFields(t *testing.T) {
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+	s := storage.NewMemorySongStorage(logger, logger)
+	songs := NewSongUsecase(s, nil, logger, logger)
+	albums := NewAlbumUsecase(s, logger, logger)
+
+	muse, err := s.AddGroup(ctx, models.Group{Name: ptr("Muse")})
+	if err != nil {
+		t.Fatalf("AddGroup: %v", err)
+	}
+	absolution, err := albums.CreateAlbum(ctx, models.AlbumInput{Title: ptr("Absolution"), Group: &muse})
+	if err != nil {
+		t.Fatalf("CreateAlbum: %v", err)
+	}
+	id, err := s.AddSong(ctx, models.Song{Group: &muse, Name: ptr("Hysteria"), Link: ptr("https://example.com/hysteria")})
+	if err != nil {
+		t.Fatalf("AddSong: %v", err)
+	}
+
+	date := models.NewDate(2003, time.December, 1)
+	err = songs.UpdateSong(ctx, id, models.Song{
+		Name:                 ptr("Hysteria"),
+		ReleaseDate:          &date,
+		ReleaseDatePrecision: models.PrecisionMonth,
+		Text:                 ptr("It's bugging me"),
+		AlbumID:              &absolution,
+		TrackNumber:          ptr(8),
+	}, AnyVersion)
+	if err != nil {
+		t.Fatalf("UpdateSong: %v", err)
+	}
+
+	song, err := s.GetSongByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetSongByID: %v", err)
+	}
+	if song.ReleaseDate == nil || *song.ReleaseDate != date || song.ReleaseDatePrecision != models.PrecisionMonth {
+		t.Errorf("дата релиза: %v (%s)", song.ReleaseDate, song.ReleaseDatePrecision)
+	}
+	if song.AlbumID == nil || *song.AlbumID != absolution || song.TrackNumber == nil || *song.TrackNumber != 8 {
+		t.Errorf("место в альбоме: %v, %v", song.AlbumID, song.TrackNumber)
+	}
+	if song.Link != nil || song.Text == nil || *song.Group_name != "Muse" {
+		t.Errorf("песня после замены: %+v", song)
+	}
+
+	// Смена группы через замену проверяется по альбому, как в PatchSong
+	err = songs.UpdateSong(ctx, id, models.Song{Name: ptr("Hysteria"), Group_name: ptr("Placebo"), AlbumID: &absolution}, AnyVersion)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Fields[0].Field != "album" {
+		t.Errorf("замена с альбомом другой группы: ошибка %v, ожидалась ошибка поля album", err)
+	}
+
+	if err := songs.UpdateSong(ctx, id, models.Song{Name: ptr("Hysteria"), Group_name: ptr("Placebo")}, AnyVersion); err != nil {
+		t.Fatalf("UpdateSong с новой группой: %v", err)
+	}
+	song, _ = s.GetSongByID(ctx, id)
+	if *song.Group_name != "Placebo" || song.ReleaseDate != nil || song.AlbumID != nil || song.TrackNumber != nil {
+		t.Errorf("песня после замены без необязательных полей: %+v", song)
+	}
+}
@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"effectiveMobile/internal/validation"
+	"effectiveMobile/models"
+)
+
+// validateSong нормализует поля песни и проверяет их перед сохранением
+func validateSong(song *models.Song, v *validation.Errors) {
+	v.String("song", song.Name, validation.SongName)
+	v.ID("group", song.Group)
+	if song.Group_name != nil {
+		v.String("group_name", song.Group_name, validation.GroupName)
+	}
+	v.ReleaseDate("releaseDate", song.ReleaseDate)
+	v.String("text", song.Text, validation.Lyrics)
+	v.Link("link", song.Link)
+}
+
+// validateSongPatch проверяет поля документа merge patch. Явный null
+// допустим только для полей, которые у песни могут быть пустыми.
+func validateSongPatch(patch *models.SongPatch, v *validation.Errors) {
+	if patch.Name.Set {
+		if patch.Name.Value == nil {
+			v.Add("song", "название песни нельзя очистить")
+		} else {
+			v.String("song", patch.Name.Value, validation.SongName)
+		}
+	}
+
+	if patch.Group.Set && patch.GroupName.Set {
+		v.Add("group", "укажите только group или group_name")
+	}
+	if patch.Group.Set {
+		if patch.Group.Value == nil {
+			v.Add("group", "у песни должна быть группа")
+		}
+		v.ID("group", patch.Group.Value)
+	}
+	if patch.GroupName.Set {
+		if patch.GroupName.Value == nil {
+			v.Add("group_name", "у песни должна быть группа")
+		} else {
+			v.String("group_name", patch.GroupName.Value, validation.GroupName)
+		}
+	}
+
+	if patch.Text.Set {
+		v.String("text", patch.Text.Value, validation.Lyrics)
+	}
+	if patch.Link.Set {
+		v.Link("link", patch.Link.Value)
+	}
+
+	if patch.Album.Set {
+		v.ID("album", patch.Album.Value)
+	}
+	if patch.DiscNumber.Set {
+		v.Position("discNumber", patch.DiscNumber.Value, validation.MaxDiscNumber)
+	}
+	if patch.TrackNumber.Set {
+		v.Position("trackNumber", patch.TrackNumber.Value, validation.MaxTrackNumber)
+	}
+}
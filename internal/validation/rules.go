@@ -0,0 +1,216 @@
+package validation
+
+import (
+	"effectiveMobile/models"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Text — правило для строкового поля
+type Text struct {
+	Required bool
+	// Максимальная длина в символах после нормализации
+	MaxLen int
+	// Разрешены переводы строк и табуляция, как в тексте песни
+	Multiline bool
+}
+
+// Правила для полей песни, группы и альбома
+var (
+	SongName   = Text{Required: true, MaxLen: 200}
+	GroupName  = Text{Required: true, MaxLen: 200}
+	AlbumTitle = Text{Required: true, MaxLen: 200}
+	Lyrics     = Text{MaxLen: 20000, Multiline: true}
+	Bio        = Text{MaxLen: 5000, Multiline: true}
+	Genre      = Text{Required: true, MaxLen: 50}
+)
+
+// MaxGenres — сколько жанров можно указать у группы
+const MaxGenres = 20
+
+// Наибольшие номера диска и трека на диске
+const (
+	MaxDiscNumber  = 99
+	MaxTrackNumber = 999
+)
+
+// Самый ранний год основания группы, как и дата релиза, ограничен
+// появлением звукозаписи
+const earliestFormedYear = 1860
+
+// LinkMaxLen — максимальная длина ссылки на песню в байтах
+const LinkMaxLen = 2048
+
+// LinkSchemes — схемы, допустимые в ссылке на песню
+var LinkSchemes = []string{"http", "https"}
+
+// Границы даты релиза: первые звукозаписи появились в 1860 году,
+// а анонсированный релиз может быть не дальше чем через год
+var earliestRelease = time.Date(1860, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+const releaseAnnounceWindow = 365 * 24 * time.Hour
+
+// String обрезает пробелы по краям, приводит строку к NFC и проверяет
+// её по правилу. Значение nil допустимо только для необязательного поля.
+func (e *Errors) String(field string, value *string, rule Text) {
+	if value == nil {
+		if rule.Required {
+			e.Add(field, "обязательное поле")
+		}
+		return
+	}
+
+	if !utf8.ValidString(*value) {
+		e.Add(field, "строка не в кодировке UTF-8")
+		return
+	}
+
+	*value = norm.NFC.String(strings.TrimSpace(*value))
+
+	if rule.Required && *value == "" {
+		e.Add(field, "не должно быть пустым")
+		return
+	}
+	if n := utf8.RuneCountInString(*value); rule.MaxLen > 0 && n > rule.MaxLen {
+		e.Add(field, fmt.Sprintf("не длиннее %d символов, передано %d", rule.MaxLen, n))
+		return
+	}
+	if strings.IndexFunc(*value, forbiddenRune(rule.Multiline)) >= 0 {
+		e.Add(field, "содержит управляющие символы")
+	}
+}
+
+func forbiddenRune(multiline bool) func(rune) bool {
+	return func(r rune) bool {
+		if multiline && (r == '\n' || r == '\r' || r == '\t') {
+			return false
+		}
+		return unicode.IsControl(r)
+	}
+}
+
+// Link проверяет ссылку: абсолютный URL с разрешённой схемой и хостом.
+// Пустая строка означает, что ссылки нет.
+func (e *Errors) Link(field string, value *string) {
+	if value == nil {
+		return
+	}
+
+	*value = strings.TrimSpace(*value)
+	if *value == "" {
+		return
+	}
+
+	if len(*value) > LinkMaxLen {
+		e.Add(field, fmt.Sprintf("не длиннее %d байт", LinkMaxLen))
+		return
+	}
+
+	link, err := url.Parse(*value)
+	switch {
+	case err != nil:
+		e.Add(field, "должно быть абсолютным URL")
+	case !slices.Contains(LinkSchemes, strings.ToLower(link.Scheme)):
+		e.Add(field, "допустимые схемы: "+strings.Join(LinkSchemes, ", "))
+	case link.Host == "":
+		e.Add(field, "в ссылке нет адреса сайта")
+	}
+}
+
+// ReleaseDate проверяет, что дата релиза правдоподобна
+func (e *Errors) ReleaseDate(field string, date *models.Date) {
+	if date == nil {
+		return
+	}
+
+	latest := time.Now().Add(releaseAnnounceWindow)
+	switch {
+	case date.Before(earliestRelease):
+		e.Add(field, "не раньше "+earliestRelease.Format("2006-01-02"))
+	case date.After(latest):
+		e.Add(field, "не позже "+latest.Format("2006-01-02"))
+	}
+}
+
+// ID проверяет идентификатор, переданный в теле запроса
+func (e *Errors) ID(field string, id *int) {
+	if id != nil && *id <= 0 {
+		e.Add(field, "должно быть положительным числом")
+	}
+}
+
+// Country приводит код страны к верхнему регистру и проверяет, что это
+// код ISO 3166-1 alpha-2. Пустая строка означает, что страна не указана.
+func (e *Errors) Country(field string, value *string) {
+	if value == nil {
+		return
+	}
+
+	*value = strings.ToUpper(strings.TrimSpace(*value))
+	if *value == "" {
+		return
+	}
+
+	if len(*value) != 2 || strings.IndexFunc(*value, func(r rune) bool { return r < 'A' || r > 'Z' }) >= 0 {
+		e.Add(field, "двухбуквенный код страны ISO 3166-1, например GB")
+	}
+}
+
+// FormedYear проверяет год основания группы
+func (e *Errors) FormedYear(field string, year *int) {
+	if year == nil {
+		return
+	}
+
+	if latest := time.Now().Year(); *year < earliestFormedYear || *year > latest {
+		e.Add(field, fmt.Sprintf("должно быть от %d до %d", earliestFormedYear, latest))
+	}
+}
+
+// Genres нормализует жанры: обрезает пробелы, приводит к нижнему регистру
+// и убирает повторы, сохраняя порядок
+func (e *Errors) Genres(field string, genres *models.Genres) {
+	if len(*genres) > MaxGenres {
+		e.Add(field, fmt.Sprintf("не больше %d жанров", MaxGenres))
+		return
+	}
+
+	normalized := models.Genres{}
+	for i, genre := range *genres {
+		genre = strings.ToLower(genre)
+		e.String(fmt.Sprintf("%s[%d]", field, i), &genre, Genre)
+		if !slices.Contains(normalized, genre) {
+			normalized = append(normalized, genre)
+		}
+	}
+	*genres = normalized
+}
+
+// Position проверяет номер диска или трека: от 1 до max
+func (e *Errors) Position(field string, value *int, max int) {
+	if value != nil && (*value < 1 || *value > max) {
+		e.Add(field, fmt.Sprintf("должно быть от 1 до %d", max))
+	}
+}
+
+// AlbumType приводит вид альбома к нижнему регистру и проверяет его.
+// Пустое значение означает, что вид не указан.
+func (e *Errors) AlbumType(field string, value *models.AlbumType) {
+	*value = models.AlbumType(strings.ToLower(strings.TrimSpace(string(*value))))
+	if *value == "" || slices.Contains(models.AlbumTypes, *value) {
+		return
+	}
+
+	names := make([]string, len(models.AlbumTypes))
+	for i, albumType := range models.AlbumTypes {
+		names[i] = string(albumType)
+	}
+	e.Add(field, "допустимые значения: "+strings.Join(names, ", "))
+}
@@ -0,0 +1,170 @@
+package validation_test
+
+import (
+	"effectiveMobile/internal/validation"
+	"effectiveMobile/models"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fields возвращает поля с ошибками, собранные проверкой
+func fields(v *validation.Errors) []string {
+	var invalid *validation.Error
+	if !errors.As(v.Err(), &invalid) {
+		return nil
+	}
+
+	names := make([]string, len(invalid.Fields))
+	for i, field := range invalid.Fields {
+		names[i] = field.Field
+	}
+	return names
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		name  string
+		value *string
+		rule  validation.Text
+		want  string
+		valid bool
+	}{
+		{"пробелы по краям", ptr("  Muse \n"), validation.SongName, "Muse", true},
+		{"NFC", ptr("Beyonce\u0301"), validation.GroupName, "Beyonc\u00e9", true},
+		{"обязательное поле не задано", nil, validation.SongName, "", false},
+		{"необязательное поле не задано", nil, validation.Lyrics, "", true},
+		{"только пробелы", ptr(" \t "), validation.SongName, "", false},
+		{"длина в символах, а не в байтах", ptr(strings.Repeat("я", 200)), validation.SongName, strings.Repeat("я", 200), true},
+		{"слишком длинное", ptr(strings.Repeat("я", 201)), validation.SongName, "", false},
+		{"длина после NFC", ptr(strings.Repeat("e\u0301", 200)), validation.SongName, strings.Repeat("\u00e9", 200), true},
+		{"управляющий символ", ptr("Mu\x00se"), validation.SongName, "", false},
+		{"перевод строки в названии", ptr("Hysteria\nLive"), validation.SongName, "", false},
+		{"переводы строк в тексте", ptr("It's bugging me\n\tgrating me"), validation.Lyrics, "It's bugging me\n\tgrating me", true},
+		{"escape-последовательность в тексте", ptr("bugging \x1b[31m me"), validation.Lyrics, "", false},
+		{"не UTF-8", ptr("Mus\xff"), validation.SongName, "", false},
+	}
+
+	for _, tt := range tests {
+		var v validation.Errors
+		v.String("song", tt.value, tt.rule)
+
+		if valid := v.Err() == nil; valid != tt.valid {
+			t.Errorf("%s: ошибка %v, ожидалась правильность %v", tt.name, v.Err(), tt.valid)
+			continue
+		}
+		if tt.valid && tt.value != nil && *tt.value != tt.want {
+			t.Errorf("%s: значение %q, ожидалось %q", tt.name, *tt.value, tt.want)
+		}
+	}
+}
+
+func TestLink(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+		valid bool
+	}{
+		{"https://www.youtube.com/watch?v=Xsp3_a-PMTw", "https://www.youtube.com/watch?v=Xsp3_a-PMTw", true},
+		{"  http://example.com  ", "http://example.com", true},
+		{"HTTPS://EXAMPLE.COM", "HTTPS://EXAMPLE.COM", true},
+		{"", "", true},
+		{"ftp://example.com/song.mp3", "", false},
+		{"javascript:alert(1)", "", false},
+		{"example.com/song", "", false},
+		{"https:///song", "", false},
+		{"https://example.com/%zz", "", false},
+		{"https://example.com/" + strings.Repeat("a", validation.LinkMaxLen), "", false},
+	}
+
+	for _, tt := range tests {
+		value := tt.value
+		var v validation.Errors
+		v.Link("link", &value)
+
+		if valid := v.Err() == nil; valid != tt.valid {
+			t.Errorf("Link(%.40q): ошибка %v, ожидалась правильность %v", tt.value, v.Err(), tt.valid)
+			continue
+		}
+		if tt.valid && value != tt.want {
+			t.Errorf("Link(%q) = %q, ожидалось %q", tt.value, value, tt.want)
+		}
+	}
+}
+
+func TestReleaseDate(t *testing.T) {
+	today := models.DateOf(time.Now())
+	tests := []struct {
+		date  *models.Date
+		valid bool
+	}{
+		{nil, true},
+		{ptr(models.NewDate(1860, time.January, 1)), true},
+		{ptr(models.NewDate(1859, time.December, 31)), false},
+		{&today, true},
+		{ptr(models.DateOf(time.Now().AddDate(0, 6, 0))), true},
+		{ptr(models.DateOf(time.Now().AddDate(2, 0, 0))), false},
+	}
+
+	for _, tt := range tests {
+		var v validation.Errors
+		v.ReleaseDate("releaseDate", tt.date)
+
+		if valid := v.Err() == nil; valid != tt.valid {
+			t.Errorf("ReleaseDate(%v): ошибка %v, ожидалась правильность %v", tt.date, v.Err(), tt.valid)
+		}
+	}
+}
+
+func TestGroupFields(t *testing.T) {
+	var v validation.Errors
+
+	country := " gb "
+	v.Country("country", &country)
+	genres := models.Genres{" Rock", "rock", "Alternative Rock "}
+	v.Genres("genres", &genres)
+	if err := v.Err(); err != nil {
+		t.Fatalf("правильные поля группы: %v", err)
+	}
+	if country != "GB" || !slices.Equal(genres, models.Genres{"rock", "alternative rock"}) {
+		t.Errorf("нормализация: страна %q, жанры %q", country, genres)
+	}
+
+	for _, value := range []string{"GBR", "1A", "гб"} {
+		var v validation.Errors
+		v.Country("country", &value)
+		if v.Err() == nil {
+			t.Errorf("Country(%q) принят", value)
+		}
+	}
+}
+
+// Ошибки всех полей собираются в одну *Error в порядке проверки
+func TestErrors(t *testing.T) {
+	var v validation.Errors
+	if err := v.Err(); err != nil {
+		t.Fatalf("пустой набор ошибок: %v", err)
+	}
+
+	v.String("song", nil, validation.SongName)
+	v.Link("link", ptr("ftp://example.com"))
+	v.Position("trackNumber", ptr(0), validation.MaxTrackNumber)
+	v.ID("group", ptr(5))
+
+	err := v.Err()
+	if !errors.Is(err, validation.ErrInvalid) {
+		t.Errorf("ошибка %v не ErrInvalid", err)
+	}
+	if got, want := fields(&v), []string{"song", "link", "trackNumber"}; !slices.Equal(got, want) {
+		t.Errorf("поля с ошибками %v, ожидались %v", got, want)
+	}
+	if !strings.Contains(err.Error(), "trackNumber: должно быть от 1 до 999") {
+		t.Errorf("текст ошибки: %q", err)
+	}
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
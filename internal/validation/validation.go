@@ -0,0 +1,57 @@
+// Package validation проверяет и нормализует данные запросов. Ошибки
+// собираются по всем полям сразу, чтобы клиент получил их одним ответом 422.
+package validation
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalid — общая ошибка для любого неправильного запроса
+var ErrInvalid = errors.New("данные не прошли проверку")
+
+// FieldError описывает неправильное значение одного поля или параметра
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error перечисляет все неправильные поля запроса.
+// errors.Is(err, ErrInvalid) истинно для любой Error.
+type Error struct {
+	Fields []FieldError
+}
+
+func (e *Error) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		parts[i] = field.Field + ": " + field.Message
+	}
+	return ErrInvalid.Error() + ": " + strings.Join(parts, "; ")
+}
+
+func (e *Error) Is(target error) bool {
+	return target == ErrInvalid
+}
+
+// Field возвращает ошибку проверки одного поля
+func Field(field, message string) error {
+	return &Error{Fields: []FieldError{{Field: field, Message: message}}}
+}
+
+// Errors собирает ошибки полей. Нулевое значение готово к работе.
+type Errors struct {
+	fields []FieldError
+}
+
+func (e *Errors) Add(field, message string) {
+	e.fields = append(e.fields, FieldError{Field: field, Message: message})
+}
+
+// Err возвращает *Error со всеми собранными ошибками или nil
+func (e *Errors) Err() error {
+	if len(e.fields) == 0 {
+		return nil
+	}
+	return &Error{Fields: e.fields}
+}
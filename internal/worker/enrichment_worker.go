@@ -0,0 +1,58 @@
+// Package worker содержит фоновые задачи сервиса
+package worker
+
+import (
+	"context"
+	"effectiveMobile/internal/usecase"
+	"log"
+	"time"
+)
+
+// EnrichmentWorker периодически дополняет песни с недостающими данными
+type EnrichmentWorker struct {
+	enrichment usecase.EnrichmentUsecase
+	interval   time.Duration
+	infoLog    *log.Logger
+	errorLog   *log.Logger
+}
+
+func NewEnrichmentWorker(enrichment usecase.EnrichmentUsecase, interval time.Duration, infoLog, errorLog *log.Logger) *EnrichmentWorker {
+	return &EnrichmentWorker{
+		enrichment: enrichment,
+		interval:   interval,
+		infoLog:    infoLog,
+		errorLog:   errorLog,
+	}
+}
+
+// Run выполняет проходы обогащения до отмены ctx
+func (w *EnrichmentWorker) Run(ctx context.Context) {
+	w.infoLog.Printf("Фоновое обогащение песен запущено, интервал %s", w.interval)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.runOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			w.infoLog.Print("Фоновое обогащение песен остановлено")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *EnrichmentWorker) runOnce(ctx context.Context) {
+	result, err := w.enrichment.EnrichIncompleteSongs(ctx)
+	if err != nil {
+		w.errorLog.Printf("Ошибка фонового обогащения песен: %v", err)
+		return
+	}
+
+	if result.Scanned > 0 {
+		w.infoLog.Printf("Обогащение песен: найдено %d, дополнено %d, ошибок %d, отложено %d",
+			result.Scanned, result.Enriched, result.Failed, result.Skipped)
+	}
+}
@@ -2,24 +2,70 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	"effectiveMobile/internal/handlers"
+	"effectiveMobile/internal/infostub"
+	"effectiveMobile/internal/migrations"
 	"effectiveMobile/internal/storage"
 	"effectiveMobile/internal/usecase"
+	"effectiveMobile/internal/worker"
+	"effectiveMobile/models"
 
 	"github.com/gorilla/mux"
 )
 
-func setupRouter(songHandler *handlers.SongHandler) *mux.Router {
+func setupRouter(songHandler *handlers.SongHandler, groupHandler *handlers.GroupHandler, albumHandler *handlers.AlbumHandler, adminHandler *handlers.AdminHandler) *mux.Router {
 	router := mux.NewRouter()
-	router.HandleFunc("/api/songs", songHandler.GetAllSongs).Methods("GET")
-	router.HandleFunc("/api/song/{id:[0-9]+}", songHandler.GetSongByID).Methods("GET")
-	router.HandleFunc("/api/song/add", songHandler.AddSong).Methods("POST")
-	router.HandleFunc("/api/song/delete", songHandler.DeleteSong).Methods("DELETE")
-	router.HandleFunc("/api/song/update", songHandler.UpdateSong).Methods("PUT")
+	router.Use(handlers.RequestID, handlers.LimitBody)
+	v2 := router.PathPrefix("/api/v2").Subrouter()
+	v2.HandleFunc("/songs", songHandler.GetAllSongs).Methods("GET")
+	v2.HandleFunc("/songs", songHandler.CreateSong).Methods("POST")
+	v2.HandleFunc("/songs/search", songHandler.SearchSongs).Methods("GET")
+	v2.HandleFunc("/songs/fuzzy", songHandler.FuzzySearchSongs).Methods("GET")
+	v2.HandleFunc("/songs/{id:[0-9]+}", songHandler.GetSongByID).Methods("GET")
+	v2.HandleFunc("/songs/{id:[0-9]+}", songHandler.ReplaceSong).Methods("PUT")
+	v2.HandleFunc("/songs/{id:[0-9]+}", songHandler.PatchSong).Methods("PATCH")
+	v2.HandleFunc("/songs/{id:[0-9]+}", songHandler.RemoveSong).Methods("DELETE")
+	v2.HandleFunc("/songs/{id:[0-9]+}/lyrics", songHandler.GetSongLyrics).Methods("GET")
+	v2.HandleFunc("/groups", groupHandler.GetAllGroups).Methods("GET")
+	v2.HandleFunc("/groups", groupHandler.CreateGroup).Methods("POST")
+	v2.HandleFunc("/groups/{id:[0-9]+}", groupHandler.GetGroupByID).Methods("GET")
+	v2.HandleFunc("/groups/{id:[0-9]+}", groupHandler.UpdateGroup).Methods("PUT")
+	v2.HandleFunc("/groups/{id:[0-9]+}", groupHandler.DeleteGroup).Methods("DELETE")
+	v2.HandleFunc("/groups/{id:[0-9]+}/songs", groupHandler.GetGroupSongs).Methods("GET")
+
+	// Альбомы появились после v2 и доступны и без версии в пути
+	for _, albums := range []*mux.Router{v2.PathPrefix("/albums").Subrouter(), router.PathPrefix("/api/albums").Subrouter()} {
+		albums.HandleFunc("", albumHandler.GetAllAlbums).Methods("GET")
+		albums.HandleFunc("", albumHandler.CreateAlbum).Methods("POST")
+		albums.HandleFunc("/{id:[0-9]+}", albumHandler.GetAlbumByID).Methods("GET")
+		albums.HandleFunc("/{id:[0-9]+}", albumHandler.UpdateAlbum).Methods("PUT")
+		albums.HandleFunc("/{id:[0-9]+}", albumHandler.DeleteAlbum).Methods("DELETE")
+		albums.HandleFunc("/{id:[0-9]+}/tracks", albumHandler.GetAlbumTracks).Methods("GET")
+	}
+
+	// Маршруты v1 оставлены для совместимости и помечены устаревшими
+	router.HandleFunc("/api/songs", handlers.Deprecated("/api/v2/songs", songHandler.GetAllSongs)).Methods("GET")
+	router.HandleFunc("/api/songs/search", handlers.Deprecated("/api/v2/songs/search", songHandler.SearchSongs)).Methods("GET")
+	router.HandleFunc("/api/songs/fuzzy", handlers.Deprecated("/api/v2/songs/fuzzy", songHandler.FuzzySearchSongs)).Methods("GET")
+	router.HandleFunc("/api/song/{id:[0-9]+}", handlers.Deprecated("/api/v2/songs/{id}", songHandler.GetSongByID)).Methods("GET")
+	router.HandleFunc("/api/songs/{id:[0-9]+}/lyrics", handlers.Deprecated("/api/v2/songs/{id}/lyrics", songHandler.GetSongLyrics)).Methods("GET")
+	router.HandleFunc("/api/song/add", handlers.Deprecated("/api/v2/songs", songHandler.AddSong)).Methods("POST")
+	router.HandleFunc("/api/song/delete", handlers.Deprecated("/api/v2/songs", songHandler.DeleteSong)).Methods("DELETE")
+	router.HandleFunc("/api/song/update", handlers.Deprecated("/api/v2/songs", songHandler.UpdateSong)).Methods("PUT")
+	// Частичное обновление появилось вместе с v2 и не устарело
+	router.HandleFunc("/api/songs/{id:[0-9]+}", songHandler.PatchSong).Methods("PATCH")
+
+	router.HandleFunc("/api/admin/info-breaker", adminHandler.GetInfoBreaker).Methods("GET")
+	router.HandleFunc("/api/admin/db-stats", adminHandler.GetDBStats).Methods("GET")
+	router.HandleFunc("/api/admin/groups/merge", groupHandler.MergeGroups).Methods("POST")
 
 	return router
 }
@@ -51,27 +97,224 @@ func setupLoggers() (*log.Logger, *log.Logger, *os.File, *os.File) {
 	return infoLog, errorLog, infoFile, errorFile
 }
 
+// setupSongInfoClient настраивает клиент внешнего API по переменной MUSIC_INFO_URL.
+// Значение "stub" запускает встроенную заглушку API, её останавливает
+// возвращаемая функция.
+func setupSongInfoClient(infoLog *log.Logger) (usecase.ResilientSongInfoClient, func()) {
+	infoURL := os.Getenv("MUSIC_INFO_URL")
+	closeStub := func() {}
+
+	switch infoURL {
+	case "":
+		infoLog.Print("MUSIC_INFO_URL не задан, песни добавляются без обогащения")
+		return nil, closeStub
+	case "stub":
+		stub := infostub.NewServer()
+		infoLog.Printf("Запущена заглушка API информации о песнях на %s", stub.URL)
+		infoURL = stub.URL
+		closeStub = stub.Close
+	}
+
+	config := usecase.DefaultResilienceConfig()
+	config.Timeout = durationFromEnv("MUSIC_INFO_TIMEOUT", config.Timeout)
+	config.OpenTimeout = durationFromEnv("MUSIC_INFO_BREAKER_TIMEOUT", config.OpenTimeout)
+	config.MaxRetries = intFromEnv("MUSIC_INFO_RETRIES", config.MaxRetries)
+	config.FailureThreshold = intFromEnv("MUSIC_INFO_BREAKER_THRESHOLD", config.FailureThreshold)
+
+	client := usecase.NewSongInfoClient(infoURL, &http.Client{})
+	return usecase.NewResilientSongInfoClient(client, config), closeStub
+}
+
+// seedDemoSongs заполняет хранилище в памяти теми же песнями,
+// что и миграция начальных данных Postgres
+func seedDemoSongs(ctx context.Context, songStorage storage.SongStorage) error {
+	demo := []struct{ group, song, text, link string }{
+		{"Imagine Dragons", "Believer", "I am believer", "https://youtu.be/7wtfhZwyrcc?si=AhOKtDFQw19Cmfmy"},
+		{"Imagine Dragons", "Thunder", "Before the thunder", "https://youtu.be/fKopy74weus?si=PPbCVQS28w3Fp0Ga"},
+		{"Linkin Park", "In the end", "It doens't even matter", "https://youtu.be/eVTXPUF4Oz4?si=XRrAbzJJqOO4jAJx"},
+	}
+
+	return songStorage.WithTx(ctx, func(tx storage.SongStorage) error {
+		for _, d := range demo {
+			groupID, err := tx.AddGroup(ctx, models.Group{Name: &d.group})
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.AddSong(ctx, models.Song{Group: &groupID, Name: &d.song, Text: &d.text, Link: &d.link})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func setupEnrichmentWorker(songStorage storage.SongStorage, infoClient usecase.SongInfoClient, infoLog, errorLog *log.Logger) *worker.EnrichmentWorker {
+	config := usecase.DefaultEnrichmentConfig()
+	config.BatchSize = intFromEnv("ENRICH_BATCH_SIZE", config.BatchSize)
+	config.Concurrency = intFromEnv("ENRICH_CONCURRENCY", config.Concurrency)
+	config.MaxAttempts = intFromEnv("ENRICH_MAX_ATTEMPTS", config.MaxAttempts)
+	config.RetryDelay = durationFromEnv("ENRICH_RETRY_DELAY", config.RetryDelay)
+
+	enrichment := usecase.NewEnrichmentUsecase(songStorage, infoClient, config, infoLog, errorLog)
+	interval := durationFromEnv("ENRICH_INTERVAL", time.Minute)
+
+	return worker.NewEnrichmentWorker(enrichment, interval, infoLog, errorLog)
+}
+
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func intFromEnv(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func envOrDefault(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// migrateOnly выполняет подкоманду migrate и сообщает, что сервис запускать
+// не нужно. Без подкоманды применяет новые миграции, если это не отключено
+// переменной MIGRATE_ON_START=false.
+func migrateOnly(migrator *migrations.Migrator) bool {
+	if flag.Arg(0) == "migrate" {
+		if err := runMigrateCommand(context.Background(), migrator, flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return true
+	}
+
+	if os.Getenv("MIGRATE_ON_START") != "false" {
+		if _, err := migrator.Up(context.Background()); err != nil {
+			log.Fatalf("Ошибка применения миграций: %v", err)
+		}
+	}
+	return false
+}
+
+// refreshSearchKeys заполняет поисковые ключи строк, добавленных в обход приложения.
+// Песни-дубликаты не мешают запуску, но сообщаются при каждом старте, пока их не исправят.
+func refreshSearchKeys(songStorage storage.SongStorage, infoLog, errorLog *log.Logger) {
+	updated, err := songStorage.RefreshSearchKeys(context.Background())
+	if updated > 0 {
+		infoLog.Printf("Заполнено поисковых ключей: %d", updated)
+	}
+
+	var duplicates *storage.DuplicateNamesError
+	switch {
+	case errors.As(err, &duplicates):
+		errorLog.Printf("Песни %v повторяют названия других песен своих групп, переименуйте или удалите их", duplicates.IDs)
+	case err != nil:
+		errorLog.Printf("Ошибка заполнения поисковых ключей: %v", err)
+	}
+}
+
 func main() {
+	storageKind := flag.String("storage", envOrDefault("STORAGE", "postgres"), "хранилище песен: postgres, sqlite или memory")
+	flag.Parse()
+
 	// Настройка логгеров
 	infoLog, errorLog, infoFile, errorFile := setupLoggers()
 	defer infoFile.Close()
 	defer errorFile.Close()
 
-	// Подключаем базу
-	conn, err := storage.GetPostgres()
-	if err != nil {
-		log.Panic("Ошибка соединения с базой данных")
+	var songStorage storage.SongStorage
+	var dbStats func() storage.PoolStats
+
+	switch *storageKind {
+	case "memory":
+		if flag.Arg(0) == "migrate" {
+			log.Fatal("Миграции доступны только для хранилищ postgres и sqlite")
+		}
+
+		songStorage = storage.NewMemorySongStorage(infoLog, errorLog)
+		if err := seedDemoSongs(context.Background(), songStorage); err != nil {
+			log.Fatalf("Ошибка заполнения демонстрационных данных: %v", err)
+		}
+		infoLog.Print("Песни хранятся в памяти и будут потеряны при остановке")
+
+	case "postgres":
+		// Подключаем базу
+		pool, err := storage.GetPostgres()
+		if err != nil {
+			log.Panic("Ошибка соединения с базой данных")
+		}
+
+		defer pool.Close()
+
+		// Миграции схемы
+		migrationList, err := migrations.Postgres()
+		if err != nil {
+			log.Fatalf("Ошибка загрузки миграций: %v", err)
+		}
+		if migrateOnly(migrations.NewMigrator(pool, migrationList, infoLog)) {
+			return
+		}
+
+		songStorage = storage.NewSongStorage(pool, infoLog, errorLog)
+		dbStats = func() storage.PoolStats { return storage.GetPoolStats(pool) }
+		refreshSearchKeys(songStorage, infoLog, errorLog)
+
+	case "sqlite":
+		db, err := storage.OpenSQLite(envOrDefault("SQLITE_PATH", "music.db"))
+		if err != nil {
+			log.Fatalf("Ошибка открытия базы SQLite: %v", err)
+		}
+
+		defer db.Close()
+
+		migrationList, err := migrations.SQLite()
+		if err != nil {
+			log.Fatalf("Ошибка загрузки миграций: %v", err)
+		}
+		if migrateOnly(migrations.NewSQLiteMigrator(db, migrationList, infoLog)) {
+			return
+		}
+
+		songStorage = storage.NewSQLiteSongStorage(db, infoLog, errorLog)
+		refreshSearchKeys(songStorage, infoLog, errorLog)
+
+	default:
+		log.Fatalf("Неизвестное хранилище %q", *storageKind)
 	}
 
-	defer conn.Close(context.Background())
+	// Клиент внешнего API информации о песнях
+	infoClient, closeStub := setupSongInfoClient(infoLog)
+	defer closeStub()
 
 	// Инициализация слоёв
-	songStorage := storage.NewSongStorage(conn, infoLog, errorLog)
-	songUsecase := usecase.NewSongUsecase(songStorage, infoLog, errorLog)
+	songUsecase := usecase.NewSongUsecase(songStorage, infoClient, infoLog, errorLog)
 	songHandler := handlers.NewSongHandler(songUsecase, infoLog, errorLog)
+	groupUsecase := usecase.NewGroupUsecase(songStorage, infoLog, errorLog)
+	groupHandler := handlers.NewGroupHandler(groupUsecase, infoLog, errorLog)
+	albumUsecase := usecase.NewAlbumUsecase(songStorage, infoLog, errorLog)
+	albumHandler := handlers.NewAlbumHandler(albumUsecase, infoLog, errorLog)
+	adminHandler := handlers.NewAdminHandler(infoClient, dbStats, infoLog, errorLog)
+
+	// Фоновое обогащение песен с недостающими данными
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	if infoClient != nil {
+		go setupEnrichmentWorker(songStorage, infoClient, infoLog, errorLog).Run(workerCtx)
+	}
 
 	// Настройка роутера
-	router := setupRouter(songHandler)
+	router := setupRouter(songHandler, groupHandler, albumHandler, adminHandler)
 
 	// Создаем новую структуру http.Server, оставляем тот же адрес и роутер, а для ошибок используем наш логгер
 	srv := &http.Server{
@@ -82,7 +325,7 @@ func main() {
 
 	infoLog.Printf("Запуск сервера на %s", srv.Addr)
 	// Вызываем метод ListenAndServe() от нашей новой структуры http.Server
-	err = srv.ListenAndServe()
+	err := srv.ListenAndServe()
 	errorLog.Fatal(err)
 	errorLog.Fatal(http.ListenAndServe(":8080", router))
 }
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"effectiveMobile/internal/migrations"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+)
+
+const migrateUsage = `Использование: migrate <команда>
+  up        применить все новые миграции
+  down [N]  откатить N последних миграций (по умолчанию 1)
+  status    показать состояние миграций
+  redo      откатить и заново применить последнюю миграцию`
+
+// runMigrateCommand выполняет подкоманду migrate
+func runMigrateCommand(ctx context.Context, migrator *migrations.Migrator, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("не указана команда\n%s", migrateUsage)
+	}
+
+	switch args[0] {
+	case "up":
+		count, err := migrator.Up(ctx)
+		fmt.Printf("Применено миграций: %d\n", count)
+		return err
+
+	case "down":
+		n := 1
+		if len(args) > 1 {
+			var err error
+			if n, err = strconv.Atoi(args[1]); err != nil || n < 1 {
+				return fmt.Errorf("неправильное число миграций %q", args[1])
+			}
+		}
+		count, err := migrator.Down(ctx, n)
+		fmt.Printf("Откачено миграций: %d\n", count)
+		return err
+
+	case "redo":
+		return migrator.Redo(ctx)
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ВЕРСИЯ\tИМЯ\tПРИМЕНЕНА")
+		for _, status := range statuses {
+			appliedAt := "нет"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+
+	default:
+		return fmt.Errorf("неизвестная команда %q\n%s", args[0], migrateUsage)
+	}
+}
@@ -0,0 +1,60 @@
+package models
+
+// AlbumType — вид релиза
+type AlbumType string
+
+const (
+	AlbumLP          AlbumType = "lp"
+	AlbumEP          AlbumType = "ep"
+	AlbumSingle      AlbumType = "single"
+	AlbumCompilation AlbumType = "compilation"
+)
+
+var AlbumTypes = []AlbumType{AlbumLP, AlbumEP, AlbumSingle, AlbumCompilation}
+
+// Album — альбом группы. У сборника группы может не быть.
+type Album struct {
+	ID        int     `json:"id"`
+	Title     *string `json:"title"`
+	Group     *int    `json:"group" db:"group_id"`
+	GroupName *string `json:"group_name" db:"group_name"`
+	// Дата релиза с точностью, как у песни
+	ReleaseDate          *Date         `json:"releaseDate" db:"release_date"`
+	ReleaseDatePrecision DatePrecision `json:"releaseDatePrecision,omitempty" db:"release_date_precision"`
+	Type                 AlbumType     `json:"type" db:"album_type"`
+	// Ссылка на изображение обложки
+	Cover *string `json:"cover"`
+	// Число песен альбома, заполняется только в ответах
+	TrackCount int `json:"trackCount" db:"track_count"`
+}
+
+// AlbumInput — тело запроса создания и замены альбома. Группа задаётся
+// по ID или по названию, дата релиза — в любом формате ParseDate.
+type AlbumInput struct {
+	Title       *string   `json:"title"`
+	Group       *int      `json:"group"`
+	GroupName   *string   `json:"group_name"`
+	ReleaseDate *string   `json:"releaseDate"`
+	Type        AlbumType `json:"type"`
+	Cover       *string   `json:"cover"`
+}
+
+// AlbumFilter — фильтры списка альбомов. Пустые поля не ограничивают выборку.
+type AlbumFilter struct {
+	// Часть названия, сравнивается по поисковому ключу
+	Title   string
+	GroupID *int
+	Type    AlbumType
+}
+
+// AlbumPage — страница альбомов с общим числом подходящих альбомов
+type AlbumPage struct {
+	Items []Album `json:"items"`
+	Total int     `json:"total"`
+}
+
+// AlbumTracks — альбом и его песни в порядке дисков и номеров треков
+type AlbumTracks struct {
+	Album  Album  `json:"album"`
+	Tracks []Song `json:"tracks"`
+}
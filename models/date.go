@@ -0,0 +1,147 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DatePrecision — точность, с которой известна дата релиза
+type DatePrecision string
+
+const (
+	PrecisionDay   DatePrecision = "day"
+	PrecisionMonth DatePrecision = "month"
+	PrecisionYear  DatePrecision = "year"
+)
+
+// DateLayout — канонический формат даты в ответах API и в базе
+const DateLayout = "2006-01-02"
+
+var ErrInvalidDate = errors.New("неизвестный формат даты")
+
+// ErrPartialDate — неполная дата там, где её точность негде сохранить
+var ErrPartialDate = errors.New("дата должна быть указана с точностью до дня")
+
+// Date — календарная дата без времени и часового пояса. В JSON всегда
+// выводится в формате YYYY-MM-DD; при неполной дате это первый день периода.
+type Date struct {
+	time.Time
+}
+
+func NewDate(year int, month time.Month, day int) Date {
+	return Date{time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+// DateOf отбрасывает у момента времени время суток
+func DateOf(t time.Time) Date {
+	return NewDate(t.Year(), t.Month(), t.Day())
+}
+
+func (d Date) String() string {
+	return d.Format(DateLayout)
+}
+
+// End возвращает последний день периода, который обозначает дата
+// с точностью precision: месяца или года
+func (d Date) End(precision DatePrecision) Date {
+	switch precision {
+	case PrecisionYear:
+		return NewDate(d.Year(), time.December, 31)
+	case PrecisionMonth:
+		return NewDate(d.Year(), d.Month()+1, 0)
+	}
+	return d
+}
+
+// FormatPrecision выводит дату в формате, который ParseDate разберёт
+// с той же точностью: YYYY-MM-DD, YYYY-MM или YYYY
+func (d Date) FormatPrecision(precision DatePrecision) string {
+	for _, format := range dateLayouts {
+		if format.precision == precision {
+			return d.Format(format.layout)
+		}
+	}
+	return d.String()
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON принимает форматы ParseDate с точностью до дня. Неполную
+// дату отклоняет: её точность потерялась бы, структуры с датой релиза
+// разбирают её сами вместе с точностью.
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	date, precision, err := ParseDate(value)
+	if err != nil {
+		return err
+	}
+	if precision != PrecisionDay {
+		return fmt.Errorf("%w: %q", ErrPartialDate, value)
+	}
+	*d = date
+	return nil
+}
+
+// Scan читает дату из базы: Postgres отдаёт time.Time,
+// SQLite — time.Time или строку YYYY-MM-DD
+func (d *Date) Scan(src any) error {
+	switch value := src.(type) {
+	case time.Time:
+		*d = DateOf(value)
+		return nil
+	case string:
+		return d.scanString(value)
+	case []byte:
+		return d.scanString(string(value))
+	}
+	return fmt.Errorf("дату нельзя прочитать из %T", src)
+}
+
+func (d *Date) scanString(value string) error {
+	date, err := time.Parse(DateLayout, value)
+	if err != nil {
+		return err
+	}
+	*d = Date{date}
+	return nil
+}
+
+// Форматы дат на входе API в порядке проверки
+var dateLayouts = []struct {
+	layout    string
+	precision DatePrecision
+}{
+	{DateLayout, PrecisionDay},
+	{"02.01.2006", PrecisionDay},
+	{"2006-01", PrecisionMonth},
+	{"01.2006", PrecisionMonth},
+	{"2006", PrecisionYear},
+}
+
+// ParseDate разбирает дату релиза и определяет её точность. Форматы:
+// DD.MM.YYYY (формат внешнего API), YYYY-MM-DD и время по ISO 8601
+// (RFC 3339) — точность до дня; YYYY-MM и MM.YYYY — до месяца; YYYY — до года.
+func ParseDate(value string) (Date, DatePrecision, error) {
+	value = strings.TrimSpace(value)
+
+	for _, format := range dateLayouts {
+		if date, err := time.Parse(format.layout, value); err == nil {
+			return Date{date}, format.precision, nil
+		}
+	}
+
+	if moment, err := time.Parse(time.RFC3339, value); err == nil {
+		return DateOf(moment), PrecisionDay, nil
+	}
+
+	return Date{}, "", fmt.Errorf("%w: %q", ErrInvalidDate, value)
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		value     string
+		date      Date
+		precision DatePrecision
+		end       Date
+	}{
+		{"2024-03-15", NewDate(2024, time.March, 15), PrecisionDay, NewDate(2024, time.March, 15)},
+		{"15.03.2024", NewDate(2024, time.March, 15), PrecisionDay, NewDate(2024, time.March, 15)},
+		{"2024-03-15T23:30:00+03:00", NewDate(2024, time.March, 15), PrecisionDay, NewDate(2024, time.March, 15)},
+		{"2024-02", NewDate(2024, time.February, 1), PrecisionMonth, NewDate(2024, time.February, 29)},
+		{"02.2023", NewDate(2023, time.February, 1), PrecisionMonth, NewDate(2023, time.February, 28)},
+		{"2024-12", NewDate(2024, time.December, 1), PrecisionMonth, NewDate(2024, time.December, 31)},
+		{" 2006 ", NewDate(2006, time.January, 1), PrecisionYear, NewDate(2006, time.December, 31)},
+	}
+
+	for _, tt := range tests {
+		date, precision, err := ParseDate(tt.value)
+		if err != nil {
+			t.Errorf("ParseDate(%q): %v", tt.value, err)
+			continue
+		}
+		if date != tt.date || precision != tt.precision {
+			t.Errorf("ParseDate(%q) = %s, %s, ожидалось %s, %s", tt.value, date, precision, tt.date, tt.precision)
+		}
+		if end := date.End(precision); end != tt.end {
+			t.Errorf("ParseDate(%q).End = %s, ожидалось %s", tt.value, end, tt.end)
+		}
+		if again, againPrecision, err := ParseDate(date.FormatPrecision(precision)); err != nil || again != date || againPrecision != precision {
+			t.Errorf("ParseDate(%q).FormatPrecision = %q не разбирается обратно", tt.value, date.FormatPrecision(precision))
+		}
+	}
+
+	for _, value := range []string{"30.02.2020", "2023-02-29", "2024-13", "15/03/2024", ""} {
+		if date, _, err := ParseDate(value); !errors.Is(err, ErrInvalidDate) {
+			t.Errorf("ParseDate(%q) = %s, %v, ожидалась ошибка формата", value, date, err)
+		}
+	}
+}
+
+func TestDateUnmarshalJSON(t *testing.T) {
+	var date Date
+	if err := json.Unmarshal([]byte(`"15.03.2024"`), &date); err != nil || date != NewDate(2024, time.March, 15) {
+		t.Errorf("полная дата: %s, %v", date, err)
+	}
+	// Точность неполной даты в Date не помещается
+	if err := json.Unmarshal([]byte(`"2024-02"`), &date); !errors.Is(err, ErrPartialDate) {
+		t.Errorf("неполная дата: ошибка %v, ожидалась ErrPartialDate", err)
+	}
+}
+
+func TestSongUnmarshalJSON(t *testing.T) {
+	ptr := func(d Date) *Date { return &d }
+	tests := []struct {
+		body      string
+		date      *Date
+		precision DatePrecision
+	}{
+		{`{"song": "Uprising", "releaseDate": "07.09.2009"}`, ptr(NewDate(2009, time.September, 7)), PrecisionDay},
+		{`{"song": "Uprising", "releaseDate": "2009-09"}`, ptr(NewDate(2009, time.September, 1)), PrecisionMonth},
+		// Точность выводится из формата даты, а не берётся из запроса
+		{`{"song": "Uprising", "releaseDate": "2009", "releaseDatePrecision": "day"}`, ptr(NewDate(2009, time.January, 1)), PrecisionYear},
+		{`{"song": "Uprising", "releaseDate": null, "releaseDatePrecision": "year"}`, nil, ""},
+	}
+
+	for _, tt := range tests {
+		var song Song
+		if err := json.Unmarshal([]byte(tt.body), &song); err != nil {
+			t.Errorf("%s: %v", tt.body, err)
+			continue
+		}
+		if song.Name == nil || *song.Name != "Uprising" {
+			t.Errorf("%s: название %v", tt.body, song.Name)
+		}
+		if (song.ReleaseDate == nil) != (tt.date == nil) ||
+			song.ReleaseDate != nil && *song.ReleaseDate != *tt.date || song.ReleaseDatePrecision != tt.precision {
+			t.Errorf("%s: дата %v (%s), ожидалась %v (%s)", tt.body, song.ReleaseDate, song.ReleaseDatePrecision, tt.date, tt.precision)
+		}
+	}
+
+	var song Song
+	if err := json.Unmarshal([]byte(`{"releaseDate": "30.02.2020"}`), &song); !errors.Is(err, ErrInvalidDate) {
+		t.Errorf("несуществующая дата: ошибка %v, ожидалась ErrInvalidDate", err)
+	}
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Group — группа (артист) и её описание
+type Group struct {
+	ID   int     `json:"id"`
+	Name *string `json:"group" db:"group_name"`
+	// Код страны по ISO 3166-1 alpha-2, например GB
+	Country    *string `json:"country"`
+	FormedYear *int    `json:"formedYear" db:"formed_year"`
+	Genres     Genres  `json:"genres"`
+	Bio        *string `json:"bio"`
+	// Число песен группы, заполняется только в ответах
+	SongCount int `json:"songCount" db:"song_count"`
+}
+
+// Genres — жанры группы. В базе хранятся массивом JSON: jsonb в Postgres
+// и текстом в SQLite.
+type Genres []string
+
+// Scan читает жанры из JSON, который вернула база
+func (g *Genres) Scan(src any) error {
+	var data []byte
+	switch value := src.(type) {
+	case nil:
+		*g = Genres{}
+		return nil
+	case string:
+		data = []byte(value)
+	case []byte:
+		data = value
+	default:
+		return fmt.Errorf("жанры нельзя прочитать из %T", src)
+	}
+
+	genres := Genres{}
+	if err := json.Unmarshal(data, &genres); err != nil {
+		return err
+	}
+	*g = genres
+	return nil
+}
+
+// JSON возвращает жанры в том виде, в каком они записываются в базу
+func (g Genres) JSON() string {
+	if g == nil {
+		return "[]"
+	}
+	data, _ := json.Marshal([]string(g))
+	return string(data)
+}
+
+// GroupFilter — фильтры списка групп. Пустые поля не ограничивают выборку.
+type GroupFilter struct {
+	// Часть названия, сравнивается по поисковому ключу
+	Name  string
+	Genre string
+}
+
+// GroupPage — страница групп с общим числом подходящих групп
+type GroupPage struct {
+	Items []Group `json:"items"`
+	Total int     `json:"total"`
+}
+
+// MergeStrategy — что делать, если у целевой группы уже есть песня
+// с тем же названием, что у песни объединяемой группы
+type MergeStrategy string
+
+const (
+	// MergeKeepTarget — оставить песню целевой группы, дополнив её пустые поля
+	MergeKeepTarget MergeStrategy = "keep_target"
+	// MergeKeepSource — заменить песню целевой группы песней объединяемой
+	MergeKeepSource MergeStrategy = "keep_source"
+	// MergeRename — перенести песню, добавив к названию номер: «Numb (2)»
+	MergeRename MergeStrategy = "rename"
+	// MergeError — отменить объединение с ошибкой конфликта
+	MergeError MergeStrategy = "error"
+)
+
+// GroupMerge — запрос на объединение групп SourceIDs в группу TargetID
+type GroupMerge struct {
+	TargetID  int           `json:"target_id"`
+	SourceIDs []int         `json:"source_ids"`
+	Strategy  MergeStrategy `json:"strategy"`
+}
+
+// GroupMergeResult — итог объединения групп
+type GroupMergeResult struct {
+	Group Group `json:"group"`
+	// Moved — песни, перенесённые без совпадений
+	Moved int `json:"moved"`
+	// Merged — совпавшие песни, слитые в одну
+	Merged int `json:"merged"`
+	// Renamed — совпавшие песни, перенесённые под новым названием
+	Renamed int `json:"renamed"`
+}
@@ -0,0 +1,10 @@
+package models
+
+// LyricsPage — страница куплетов текста песни
+type LyricsPage struct {
+	SongID int      `json:"id"`
+	Verses []string `json:"verses"`
+	Page   int      `json:"page"`
+	Limit  int      `json:"limit"`
+	Total  int      `json:"total"`
+}
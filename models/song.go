@@ -1,17 +1,61 @@
 package models
 
-import "time"
+import "encoding/json"
 
 type Song struct {
-	ID          *int       `json:"id"`
-	Name        *string    `json:"song"`
-	Group       *int       `json:"group"`
-	Group_name  *string    `json:"group_name"`
-	ReleaseDate *time.Time `json:"releaseDate"`
-	Text        *string    `json:"text"`
-	Link        *string    `json:"link"`
+	ID          *int    `json:"id"`
+	Name        *string `json:"song"`
+	Group       *int    `json:"group"`
+	Group_name  *string `json:"group_name"`
+	ReleaseDate *Date   `json:"releaseDate"`
+	// Точность даты релиза: day, month или year
+	ReleaseDatePrecision DatePrecision `json:"releaseDatePrecision,omitempty" db:"release_date_precision"`
+	Text                 *string       `json:"text"`
+	Link                 *string       `json:"link"`
+	// Альбом песни и её место в нём: номер диска и номер трека на диске
+	AlbumID     *int `json:"album"`
+	DiscNumber  *int `json:"discNumber"`
+	TrackNumber *int `json:"trackNumber"`
+	// Песня сохранена без данных внешнего API и ждёт повторного обогащения
+	NeedsEnrichment bool `json:"needsEnrichment,omitempty"`
+	// Версия строки, клиенты получают её в заголовке ETag
+	Version int `json:"-"`
 }
 
-type Group struct {
-	Name *string `json:"group"`
+// UnmarshalJSON разбирает песню из тела запроса. Точность даты релиза
+// определяется по её формату: 2024-02 — месяц, 2024 — год.
+func (s *Song) UnmarshalJSON(data []byte) error {
+	type song Song
+	var raw struct {
+		song
+		ReleaseDate *string `json:"releaseDate"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*s = Song(raw.song)
+	s.ReleaseDate, s.ReleaseDatePrecision = nil, ""
+	if raw.ReleaseDate == nil {
+		return nil
+	}
+
+	date, precision, err := ParseDate(*raw.ReleaseDate)
+	if err != nil {
+		return err
+	}
+	s.ReleaseDate, s.ReleaseDatePrecision = &date, precision
+	return nil
 }
+
+// OnConflict — что делать при добавлении песни, которая у группы уже есть
+type OnConflict string
+
+const (
+	// OnConflictError — вернуть ошибку с ID существующей песни
+	OnConflictError OnConflict = "error"
+	// OnConflictReturn — вернуть существующую песню без изменений
+	OnConflictReturn OnConflict = "return"
+	// OnConflictUpdate — обновить существующую песню новыми данными
+	OnConflictUpdate OnConflict = "update"
+)
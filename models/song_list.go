@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// MatchMode — способ сравнения названий песни и группы с фильтром
+type MatchMode string
+
+const (
+	MatchContains MatchMode = "contains"
+	MatchPrefix   MatchMode = "prefix"
+	MatchExact    MatchMode = "exact"
+)
+
+// SongFilter — фильтры списка песен. Пустые поля не ограничивают выборку.
+type SongFilter struct {
+	Name         string
+	Group        string
+	GroupID      *int
+	Album        string
+	AlbumID      *int
+	Match        MatchMode
+	ReleasedFrom *time.Time
+	ReleasedTo   *time.Time
+	Text         string
+	HasLink      *bool
+}
+
+// Поля, по которым можно сортировать список песен
+const (
+	SortByID          = "id"
+	SortBySong        = "song"
+	SortByGroup       = "group"
+	SortByReleaseDate = "release_date"
+)
+
+var SongSortFields = []string{SortByID, SortBySong, SortByGroup, SortByReleaseDate}
+
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// Pagination — параметры постраничного получения песен.
+// Если задан курсор After, используется keyset-пагинация, иначе Page и Limit.
+// Курсор действителен только для того порядка Sort, с которым он был выдан.
+type Pagination struct {
+	Page  int
+	Limit int
+	After string
+	Sort  []SortField
+}
+
+// SongPage — страница песен с общим числом подходящих песен
+type SongPage struct {
+	Items      []Song `json:"items"`
+	Total      int    `json:"total"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// PatchField — поле JSON Merge Patch (RFC 7396). Set показывает, что поле
+// есть в документе, Value равно nil, если передан явный null.
+type PatchField[T any] struct {
+	Set   bool
+	Value *T
+}
+
+func (f *PatchField[T]) UnmarshalJSON(data []byte) error {
+	f.Set = true
+	f.Value = nil
+
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return nil
+	}
+
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	f.Value = &value
+	return nil
+}
+
+// Set возвращает поле, заданное значением value
+func Set[T any](value T) PatchField[T] {
+	return PatchField[T]{Set: true, Value: &value}
+}
+
+// SongPatch — документ merge patch для песни. Поля называются так же,
+// как в представлении песни; группу можно сменить по ID или по названию.
+type SongPatch struct {
+	Name        PatchField[string] `json:"song"`
+	Group       PatchField[int]    `json:"group"`
+	GroupName   PatchField[string] `json:"group_name"`
+	ReleaseDate PatchField[string] `json:"releaseDate"`
+	Text        PatchField[string] `json:"text"`
+	Link        PatchField[string] `json:"link"`
+	Album       PatchField[int]    `json:"album"`
+	DiscNumber  PatchField[int]    `json:"discNumber"`
+	TrackNumber PatchField[int]    `json:"trackNumber"`
+}
+
+// SongChanges — проверенные изменения песни для хранилища
+type SongChanges struct {
+	Name        PatchField[string]
+	GroupID     PatchField[int]
+	ReleaseDate PatchField[Date]
+	// Точность новой даты релиза, если она задана
+	ReleaseDatePrecision DatePrecision
+	Text                 PatchField[string]
+	Link                 PatchField[string]
+	AlbumID              PatchField[int]
+	DiscNumber           PatchField[int]
+	TrackNumber          PatchField[int]
+}
+
+// Empty сообщает, что изменений нет
+func (c SongChanges) Empty() bool {
+	return !c.Name.Set && !c.GroupID.Set && !c.ReleaseDate.Set && !c.Text.Set && !c.Link.Set &&
+		!c.AlbumID.Set && !c.DiscNumber.Set && !c.TrackNumber.Set
+}
@@ -0,0 +1,40 @@
+package models
+
+// Языки полнотекстового поиска. Пустой язык — поиск сразу на обоих.
+const (
+	SearchLangAny = ""
+	SearchLangRu  = "ru"
+	SearchLangEn  = "en"
+)
+
+type SearchQuery struct {
+	Text string
+	Lang string
+}
+
+// SongSearchHit — найденная песня с релевантностью и фрагментом текста,
+// в котором совпадения выделены тегами <b></b>
+type SongSearchHit struct {
+	Song
+	Rank    float32 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+type SongSearchPage struct {
+	Items []SongSearchHit `json:"items"`
+	Total int             `json:"total"`
+}
+
+// FuzzySongHit — песня, найденная по похожести названия или группы
+type FuzzySongHit struct {
+	Song
+	Score float32 `json:"score"`
+}
+
+// FuzzySongPage — результат нечёткого поиска. Если точных совпадений нет,
+// Suggestion содержит наиболее похожее название песни или группы.
+type FuzzySongPage struct {
+	Items      []FuzzySongHit `json:"items"`
+	ExactHits  int            `json:"exact_hits"`
+	Suggestion string         `json:"did_you_mean,omitempty"`
+}